@@ -5,12 +5,22 @@ Simplified Command Line Interface for Zed Updater
 """
 
 import sys
+import json
+import signal
 import argparse
 from pathlib import Path
+from typing import Any, Dict, List, Optional
 
-from .core.config import ConfigManager
+from .core.config import ConfigManager, SECRET_FIELDS, REDACTED
+from .core.config_schema import get_config_schema, check_value
 from .core.updater import ZedUpdater
+from .core.scheduler import UpdateScheduler
+from .core.data_migration import DataMigrator
+from .core.diagnostics import StartupDiagnostics
+from .services.task_scheduler import SystemTaskScheduler
 from .utils.logger import setup_logging, get_logger
+from .utils.paths import get_data_path
+from .utils.transfer import format_size, format_duration
 
 
 def create_parser():
@@ -23,6 +33,10 @@ Examples:
   zed-updater --check              # Check for updates
   zed-updater --update             # Download and install updates
   zed-updater --current-version    # Show current Zed version
+  zed-updater --list-backups       # List available backups
+  zed-updater --rollback           # Restore the newest backup
+  zed-updater --delete-backup NAME # Delete one backup
+  zed-updater --register-task      # Run checks from the OS task scheduler
   zed-updater --config PATH        # Use custom config file
   zed-updater --gui                # Start GUI mode
         """
@@ -46,12 +60,249 @@ Examples:
         help='Download and install Zed updates'
     )
 
+    parser.add_argument(
+        '--start-zed',
+        nargs='?',
+        const='',
+        metavar='PROFILE',
+        help='Start Zed detached with zed_launch_args, or the named zed_launch_profiles entry, and print its PID'
+    )
+
+    parser.add_argument(
+        '--zed-status',
+        action='store_true',
+        help='Show CPU, memory and uptime of the running Zed processes'
+    )
+
+    parser.add_argument(
+        '--stop-zed',
+        action='store_true',
+        help='Ask Zed to exit, kill it after zed_close_timeout if zed_force_kill is set'
+    )
+
     parser.add_argument(
         '--current-version',
         action='store_true',
         help='Show current Zed version'
     )
 
+    parser.add_argument(
+        '--ignore-size-limit',
+        action='store_true',
+        help='Download updates even if they exceed max_asset_size_mb'
+    )
+
+    parser.add_argument(
+        '--no-backup',
+        action='store_true',
+        help='Skip backing up the current version before installing'
+    )
+
+    parser.add_argument(
+        '--no-restart',
+        action='store_true',
+        help='Do not start Zed after updating'
+    )
+
+    parser.add_argument(
+        '--install-file',
+        type=str,
+        metavar='PATH',
+        help='Install an already downloaded update file'
+    )
+
+    parser.add_argument(
+        '--dry-run',
+        action='store_true',
+        help='With --update or --install-file: run all install checks and show what would happen'
+    )
+
+    parser.add_argument(
+        '--install-target',
+        action='append',
+        metavar='PATH',
+        help='Zed executable to install to, may be repeated (default: all configured install paths)'
+    )
+
+    parser.add_argument(
+        '--tag',
+        metavar='TAG',
+        help='With --update, install this release instead of the latest (allows downgrades)'
+    )
+
+    parser.add_argument(
+        '--show-release',
+        metavar='TAG',
+        help='Show a release with its date, assets and notes'
+    )
+
+    parser.add_argument(
+        '--changelog',
+        action='store_true',
+        help='Show whether an update is available and the notes of every release since the installed one'
+    )
+
+    parser.add_argument(
+        '--rate-limit',
+        action='store_true',
+        help='Show the remaining API requests of the release sources'
+    )
+
+    parser.add_argument(
+        '--metrics',
+        action='store_true',
+        help='Print check, download and install counters in the Prometheus text format'
+    )
+
+    parser.add_argument(
+        '--list-releases',
+        action='store_true',
+        help='List releases with their date, prerelease flag and assets'
+    )
+
+    parser.add_argument(
+        '--page',
+        type=int,
+        default=1,
+        help='Page of --list-releases to show (default: 1)'
+    )
+
+    parser.add_argument(
+        '--per-page',
+        type=int,
+        default=10,
+        help='Releases per page for --list-releases, at most 100 (default: 10)'
+    )
+
+    parser.add_argument(
+        '--list-backups',
+        action='store_true',
+        help='List available backups'
+    )
+
+    parser.add_argument(
+        '--restore',
+        choices=['binary', 'config', 'all'],
+        default='binary',
+        help='What --rollback restores: the executable (default), Zed settings and extensions, or both'
+    )
+
+    parser.add_argument(
+        '--verify-backups',
+        action='store_true',
+        help='Re-hash all backups and report corrupted ones'
+    )
+
+    parser.add_argument(
+        '--delete-backup',
+        metavar='BACKUP',
+        help='Delete a backup (as shown by --list-backups)'
+    )
+
+    parser.add_argument(
+        '--rollback',
+        nargs='?',
+        const='',
+        metavar='BACKUP',
+        help='Restore the newest (or the given) backup'
+    )
+
+    parser.add_argument(
+        '--register-task',
+        action='store_true',
+        help='Register a Task Scheduler task (or cron entry) that runs scheduled checks'
+    )
+
+    parser.add_argument(
+        '--unregister-task',
+        action='store_true',
+        help='Remove the scheduled check task'
+    )
+
+    parser.add_argument(
+        '--scheduled-run',
+        action='store_true',
+        help='Run one check the same way the in-app scheduler does'
+    )
+
+    parser.add_argument(
+        '--migrate-data',
+        action='store_true',
+        help='Move data from earlier versions into the unified data directory'
+    )
+
+    parser.add_argument(
+        '--export-history',
+        metavar='FILE',
+        help='Write the update history as a report, "-" for stdout'
+    )
+
+    parser.add_argument(
+        '--export-format',
+        choices=['csv', 'json'],
+        default='csv',
+        help='Format for --export-history (default: csv)'
+    )
+
+    parser.add_argument(
+        '--audit-log',
+        nargs='?',
+        const=20,
+        type=int,
+        metavar='N',
+        help='Show the last N config changes, installs, rollbacks and deleted backups (default: 20)'
+    )
+
+    parser.add_argument(
+        '--startup-report',
+        action='store_true',
+        help='Print the startup self-check as JSON and exit'
+    )
+
+    parser.add_argument(
+        '--ready',
+        action='store_true',
+        help='Check config, directories and release sources, print JSON and exit non-zero if not ready'
+    )
+
+    parser.add_argument(
+        '--set',
+        action='append',
+        metavar='KEY=VALUE',
+        help='Change one configuration value and keep the others, VALUE is JSON or plain text (repeatable)'
+    )
+
+    parser.add_argument(
+        '--reset-config',
+        nargs='*',
+        metavar='KEY',
+        help='Restore the default of the given config keys, or of every setting when none are given'
+    )
+
+    parser.add_argument(
+        '--export-config',
+        metavar='FILE',
+        help='Write the configuration to FILE, "-" for stdout; tokens are redacted'
+    )
+
+    parser.add_argument(
+        '--include-secrets',
+        action='store_true',
+        help='Keep tokens in --export-config output'
+    )
+
+    parser.add_argument(
+        '--import-config',
+        metavar='FILE',
+        help='Replace the configuration with an exported one, redacted tokens keep their current value'
+    )
+
+    parser.add_argument(
+        '--config-schema',
+        action='store_true',
+        help='Print the type, default, constraints and description of every config field as JSON'
+    )
+
     parser.add_argument(
         '--config',
         type=str,
@@ -61,8 +312,13 @@ Examples:
     parser.add_argument(
         '--log-level',
         choices=['DEBUG', 'INFO', 'WARNING', 'ERROR', 'CRITICAL'],
-        default='INFO',
-        help='Set logging level'
+        help='Set logging level (default: log_level from the config)'
+    )
+
+    parser.add_argument(
+        '--log-format',
+        choices=['text', 'json'],
+        help='Log as text or one JSON object per line (default: log_format from the config)'
     )
 
     parser.add_argument(
@@ -80,16 +336,89 @@ Examples:
     return parser
 
 
+def print_target_results(result) -> None:
+    """Print the outcome per install path when several were updated"""
+    for target in result.target_results:
+        status = "成功" if target.success else f"失败 - {target.message}"
+        print(f"  {target.install_path}: {status}")
+    if result.install_method == "dry_run":
+        print("试运行，将执行以下操作:")
+        for action in result.actions:
+            print(f"  - {action}")
+
+
+def parse_config_assignments(config: ConfigManager, assignments: List[str]) -> Dict[str, Any]:
+    """Turn --set KEY=VALUE pairs into config updates
+
+    Raises:
+        ValueError: For unknown keys or values of the wrong type
+    """
+    updates = {}
+    current = config.get_all()
+    for assignment in assignments:
+        key, sep, text = assignment.partition('=')
+        key = key.strip()
+        if not sep or key not in current:
+            raise ValueError(f"未知配置项: {key}" if sep else f"格式应为 KEY=VALUE: {assignment}")
+        try:
+            value = json.loads(text)
+        except ValueError:
+            value = text
+        expected = type(current[key])
+        if expected is str:
+            value = text
+        elif expected is float and type(value) is int:
+            value = float(value)
+        if type(value) is not expected:
+            raise ValueError(f"{key} 需要 {expected.__name__} 类型的值，得到 {text!r}")
+        error = check_value(key, value)
+        if error:
+            raise ValueError(error)
+        updates[key] = value
+    return updates
+
+
+def configure_logging(args, config: Optional[ConfigManager] = None) -> None:
+    """Set up logging from the config, command line flags take precedence"""
+    settings = config.get_all() if config else {}
+    setup_logging(
+        level=args.log_level or settings.get('log_level', 'INFO'),
+        log_file=str(get_data_path('logs') / 'zed-updater.log'),
+        max_bytes=settings.get('log_max_size_mb', 10) * 1024 * 1024,
+        backup_count=settings.get('log_backup_count', 5),
+        use_colors=not args.quiet,
+        log_format=args.log_format or settings.get('log_format', 'text'),
+        max_age_days=settings.get('log_max_age_days', 0)
+    )
+
+
+def install_shutdown_handlers(updater: ZedUpdater) -> None:
+    """Stop at a safe point on Ctrl+C or SIGTERM
+
+    Outside an install the command ends at once, a partial download is
+    kept for resuming. During one the install finishes first; a second
+    signal ends the command anyway and the next start repairs the install.
+    """
+    def handle(signum, frame):
+        finish_install = updater.is_busy() and not updater.is_shutting_down()
+        updater.request_shutdown()
+        if finish_install:
+            print("\n正在安装，完成后退出 (再按一次 Ctrl+C 立即退出)", file=sys.stderr)
+            return
+        raise KeyboardInterrupt
+
+    for name in ('SIGINT', 'SIGTERM'):
+        if hasattr(signal, name):
+            signal.signal(getattr(signal, name), handle)
+
+
 def main():
     """Main CLI entry point"""
     parser = create_parser()
     args = parser.parse_args()
 
-    # Setup logging
-    setup_logging(
-        level=args.log_level,
-        use_colors=not args.quiet
-    )
+    # Setup logging, again with the log settings once the config is loaded
+    configure_logging(args)
 
     logger = get_logger(__name__)
 
@@ -102,11 +431,101 @@ def main():
         # Load configuration
         config_file = args.config
         config = ConfigManager(config_file)
+        configure_logging(args, config)
         
         # Ensure required directories exist
         config.ensure_directories()
+
+        # Handle data migration
+        if args.migrate_data:
+            steps = DataMigrator(config).migrate()
+            for step in steps:
+                if step.status != 'moved':
+                    print(f"{step.status}: {step.source} ({step.message})")
+            moved = sum(1 for step in steps if step.status == 'moved')
+            print(f"已迁移 {moved} 个文件到 {config.get_data_root()}")
+            return 0 if all(step.status != 'failed' for step in steps) else 1
+
+        # Handle config reset
+        if args.reset_config is not None:
+            try:
+                ok = config.reset(args.reset_config)
+            except KeyError as e:
+                print(f"配置未修改: 未知配置项: {e.args[0]}")
+                return 1
+            if not ok:
+                print("保存配置失败")
+                return 1
+            if not args.reset_config:
+                print(f"已恢复全部默认设置，原配置保存为 {config.config_file.name}.bak")
+                return 0
+            for key in args.reset_config:
+                print(f"{key} = {json.dumps(config.get(key), ensure_ascii=False)}")
+            return 0
+
+        # Handle config export and import
+        if args.export_config:
+            exported = json.dumps(config.export_config(args.include_secrets), indent=2, ensure_ascii=False)
+            if args.export_config == '-':
+                print(exported)
+            else:
+                with open(args.export_config, 'w', encoding='utf-8') as f:
+                    f.write(exported + '\n')
+                print(f"配置已导出到 {args.export_config}")
+            return 0
+
+        if args.import_config:
+            try:
+                with open(args.import_config, 'r', encoding='utf-8') as f:
+                    data = json.load(f)
+                if not isinstance(data, dict):
+                    raise ValueError("文件内容不是 JSON 对象")
+            except (OSError, ValueError) as e:
+                print(f"配置未修改: 无法读取 {args.import_config}: {e}")
+                return 1
+            if not config.import_config(data):
+                print("保存配置失败")
+                return 1
+            print(f"已从 {args.import_config} 导入配置")
+            return 0
+
+        # Handle config schema
+        if args.config_schema:
+            schema = get_config_schema(config.get('language', 'zh_CN'))
+            print(json.dumps(schema, indent=2, ensure_ascii=False))
+            return 0
+
+        # Handle partial config changes
+        if args.set:
+            try:
+                updates = parse_config_assignments(config, args.set)
+            except ValueError as e:
+                print(f"配置未修改: {e}")
+                return 1
+            if not config.update(updates):
+                print("保存配置失败")
+                return 1
+            for key, value in updates.items():
+                shown = REDACTED if key in SECRET_FIELDS and value else value
+                print(f"{key} = {json.dumps(shown, ensure_ascii=False)}")
+            return 0
+
+        if DataMigrator(config).pending():
+            logger.info("发现旧版本的数据文件，可运行 --migrate-data 迁移到统一的数据目录")
         
         updater = ZedUpdater(config)
+        install_shutdown_handlers(updater)
+
+        # Also finishes installs deferred by a locked executable last time
+        diagnostics = StartupDiagnostics(config, updater)
+        startup_report = diagnostics.run()
+        if args.startup_report:
+            print(json.dumps(startup_report.to_dict(), indent=2, ensure_ascii=False))
+            return 0 if startup_report.ok else 1
+        if args.ready:
+            readiness = diagnostics.check_readiness()
+            print(json.dumps(readiness.to_dict(), indent=2, ensure_ascii=False))
+            return 0 if readiness.ok else 1
 
         # Handle GUI mode
         if args.gui:
@@ -118,6 +537,36 @@ def main():
                 print(f"无法启动GUI: {e}")
                 return 1
 
+        # Handle Zed launch
+        if args.start_zed is not None:
+            pid = updater.start_zed(args.start_zed or None)
+            if not pid:
+                print("无法启动 Zed")
+                return 1
+            print(f"Zed 已启动，PID {pid}")
+            return 0
+
+        if args.zed_status:
+            statuses = updater.get_zed_process_status()
+            if not statuses:
+                print("Zed 没有在运行")
+                return 1
+            for status in statuses:
+                print(f"PID {status['pid']}  {status['status']}  CPU {status['cpu_percent']:.1f}%  "
+                      f"内存 {format_size(status['memory_rss'])}  "
+                      f"已运行 {format_duration(status['uptime_seconds'])}  {status['exe']}")
+            return 0
+
+        if args.stop_zed:
+            stop_result = updater.stop_zed()
+            for label, pids in (("已正常退出", stop_result.closed), ("已强制结束", stop_result.killed),
+                                ("仍在运行", stop_result.running)):
+                for pid in pids:
+                    print(f"PID {pid}: {label}")
+            if not (stop_result.closed or stop_result.killed or stop_result.running):
+                print("Zed 没有在运行")
+            return 0 if stop_result.success else 1
+
         # Handle current version
         if args.current_version:
             current_version = updater.get_current_version()
@@ -128,6 +577,188 @@ def main():
                 return 1
             return 0
 
+        # Handle OS task registration
+        if args.register_task or args.unregister_task:
+            task_scheduler = SystemTaskScheduler()
+            if args.unregister_task:
+                ok = task_scheduler.unregister()
+                print("计划任务已删除" if ok else "删除计划任务失败")
+                return 0 if ok else 1
+
+            command = [sys.executable, '-m', 'zed_updater.cli', '--scheduled-run', '--quiet',
+                       '--config', str(config.config_file.resolve())]
+            ok = task_scheduler.register(
+                command,
+                check_time=config.get('check_time', ''),
+                interval_hours=config.get('check_interval_hours', 24)
+            )
+            print("计划任务已注册" if ok else "注册计划任务失败")
+            return 0 if ok else 1
+
+        # Handle a run started by the OS task scheduler
+        if args.scheduled_run:
+            if not config.get('auto_check_enabled'):
+                logger.info("自动检查已禁用，跳过计划检查")
+                return 0
+            result = UpdateScheduler(updater, config).force_check_now()
+            logger.info(f"计划检查完成: {result.message}")
+            return 0 if result.success else 1
+
+        # Handle install of a downloaded file (also used by the elevated helper)
+        if args.install_file:
+            # The file belongs to the caller, an elevated parent removes it itself
+            result = updater.install_update(
+                Path(args.install_file),
+                skip_backup=args.no_backup,
+                targets=[Path(target) for target in args.install_target or []],
+                keep_download=True,
+                dry_run=args.dry_run
+            )
+            print_target_results(result)
+            if result.success:
+                print(f"安装成功: {result.message}")
+                return 0
+            print(f"安装失败: {result.message}")
+            return 1
+
+        # Handle audit log
+        if args.audit_log is not None:
+            for entry in reversed(updater.audit.get_entries(args.audit_log)):
+                status = "成功" if entry.success else "失败"
+                details = json.dumps(entry.details, ensure_ascii=False, default=str)
+                print(f"{entry.timestamp}  {entry.user}@{entry.host}  {entry.action}  {status}  {details}")
+            return 0
+
+        # Handle history export
+        if args.export_history:
+            report = updater.history.export(args.export_format)
+            if args.export_history == '-':
+                print(report, end='' if report.endswith('\n') else '\n')
+            else:
+                # A BOM lets Excel detect the encoding of the CSV
+                encoding = 'utf-8-sig' if args.export_format == 'csv' else 'utf-8'
+                with open(args.export_history, 'w', encoding=encoding, newline='') as f:
+                    f.write(report)
+                print(f"更新历史已导出到 {args.export_history}")
+            return 0
+
+        # Handle changelog
+        if args.changelog:
+            status = updater.get_update_status()
+            if status is None:
+                print("无法获取最新版本信息")
+                return 1
+            print(f"当前版本: {status.current_version or '未安装'}")
+            print(f"最新版本: {status.latest_version}")
+            print(f"有可用更新: {'是' if status.update_available else '否'}")
+            if status.changelog:
+                print(f"\n{status.changelog}")
+            return 0
+
+        # Handle release lookup
+        if args.show_release:
+            release = updater.get_release(args.show_release)
+            if not release:
+                print(f"找不到版本 {args.show_release}")
+                return 1
+            label = "  [预发布]" if release.prerelease else ""
+            print(f"{release.version}  {release.release_date:%Y-%m-%d}{label}  ({release.source})")
+            for asset in release.assets:
+                marker = "*" if asset.download_url == release.download_url else " "
+                print(f"  {marker} {asset.name}  {asset.size} 字节")
+            if release.description:
+                print(f"\n{release.description}")
+            return 0
+
+        # Handle rate limit status
+        if args.rate_limit:
+            for name, status in updater.get_rate_limit_status():
+                auth = "已认证" if status['authenticated'] else "未认证"
+                if 'remaining' not in status:
+                    print(f"{name}  ({auth}) 未报告请求限额")
+                    continue
+                reset = f"，{status['reset']:%Y-%m-%d %H:%M:%S} 重置" if 'reset' in status else ""
+                print(f"{name}  ({auth}) 剩余 {status['remaining']}/{status.get('limit', '?')} 次请求{reset}")
+                if status['paused_until']:
+                    print(f"  请求已暂停到 {status['paused_until']:%H:%M:%S}")
+            return 0
+
+        if args.metrics:
+            # Ask for the rate limits, nothing has been requested in this run yet
+            print(updater.metrics.render(updater.get_rate_limit_status()), end='')
+            return 0
+
+        # Handle release listing
+        if args.list_releases:
+            releases = updater.list_releases(args.page, args.per_page)
+            if not releases:
+                print("没有找到发布版本")
+                return 0
+            for release in releases:
+                label = "  [预发布]" if release.prerelease else ""
+                print(f"{release.version}  {release.release_date:%Y-%m-%d}{label}")
+                for asset in release.assets:
+                    print(f"    {asset.name}  {asset.size} 字节")
+            if len(releases) >= args.per_page:
+                print(f"\n下一页: --list-releases --page {args.page + 1}")
+            return 0
+
+        # Handle list backups
+        if args.list_backups:
+            backups = updater.list_backups()
+            if not backups:
+                print("没有可用的备份")
+                return 0
+            for backup in backups:
+                print(f"{backup.name}  {backup.stat().st_size} 字节")
+            stats = updater.get_backup_stats()
+            if stats['skipped']:
+                print(f"\n因与最新备份相同跳过了 {stats['skipped']} 次备份，节省 {stats['bytes_saved']} 字节"
+                      f" (最近一次 {stats['last_skipped']}，沿用 {stats['last_reused']})")
+            mirror_status = updater.backup_mirror.get_status()
+            if updater.backup_mirror.enabled and mirror_status:
+                state = "成功" if mirror_status.get('success') else f"失败: {mirror_status.get('error')}"
+                print(f"\n上次同步到 {mirror_status.get('destination')} ({mirror_status.get('timestamp')}): {state}")
+            return 0
+
+        # Handle backup verification
+        if args.verify_backups:
+            results = updater.verify_backups()
+            if not results:
+                print("没有可用的备份")
+                return 0
+            labels = {'ok': "完好", 'corrupted': "已损坏", 'unverified': "无校验记录"}
+            for backup, status in results:
+                print(f"{backup.name}  {labels[status]}")
+            return 1 if any(status == 'corrupted' for _, status in results) else 0
+
+        # Handle backup deletion
+        if args.delete_backup:
+            result = updater.delete_backup(args.delete_backup)
+            print(result.message if result.success else f"删除失败: {result.message}")
+            return 0 if result.success else 1
+
+        # Handle rollback
+        if args.rollback is not None:
+            backup_path = None
+            if args.rollback:
+                backup_path = Path(args.rollback)
+                if not backup_path.is_absolute() and not backup_path.exists():
+                    # Backups may sit in per-version subdirectories
+                    backup_path = updater.find_backup(args.rollback) or config.get_backup_dir() / backup_path
+
+            logger.info("开始回滚...")
+            result = updater.rollback(
+                backup_path,
+                restore_binary=args.restore in ('binary', 'all'),
+                restore_config=args.restore in ('config', 'all')
+            )
+            if result.success:
+                print(f"回滚成功: {result.message}")
+                return 0
+            print(f"回滚失败: {result.message}")
+            return 1
+
         # Handle check for updates
         if args.check:
             logger.info("检查更新中...")
@@ -137,12 +768,20 @@ def main():
                 print(f"发现可用更新: {release_info.version}")
                 print(f"发布日期: {release_info.release_date}")
                 print(f"下载大小: {release_info.size} 字节")
+                if release_info.cached:
+                    print("发布信息自上次检查后未变化")
                 if release_info.description:
                     print(f"描述: {release_info.description[:200]}...")
                 return 0
-            else:
-                print("没有可用的更新")
-                return 0
+            paused = [source.describe() for source in updater.sources if source.is_rate_limited()]
+            if paused:
+                print(f"{', '.join(paused)} 的 API 请求受限，已跳过检查，详见 --rate-limit")
+                return 1
+            if updater.last_check_failed:
+                print("检查更新失败: 无法从任何发布源获取最新版本")
+                return 1
+            print("没有可用的更新")
+            return 0
 
         # Handle update
         if args.update:
@@ -152,14 +791,45 @@ def main():
                 if not args.quiet:
                     print(f"\r{message}", end='', flush=True)
 
-            result = updater.check_and_update(progress_callback)
+            crashed = []
+            updater.add_crash_callback(lambda message, rollback_result: crashed.append((message, rollback_result)))
+            result = updater.check_and_update(
+                progress_callback,
+                ignore_size_limit=args.ignore_size_limit,
+                skip_backup=args.no_backup,
+                skip_restart=args.no_restart,
+                dry_run=args.dry_run,
+                tag=args.tag
+            )
 
             if not args.quiet:
                 print()  # New line after progress
+            print_target_results(result)
 
             if result.success:
-                if result.version:
+                if result.install_method == "dry_run":
+                    print("试运行检查通过，未做任何修改")
+                elif result.install_method == "scheduled":
+                    print("Zed 正在运行，等待其退出后安装 (按 Ctrl+C 停止等待，下次启动时仍会应用)")
+                    try:
+                        updater.wait_for_deferred_installs()
+                        print("更新已安装")
+                    except KeyboardInterrupt:
+                        print()
+                elif result.version:
                     print(f"成功更新到版本 {result.version}")
+                    if (config.get('crash_watchdog_seconds', 0) > 0 and config.get('auto_start_after_update', True)
+                            and not args.no_restart):
+                        print(f"正在监视 Zed 是否正常运行 ({config.get('crash_watchdog_seconds')} 秒，按 Ctrl+C 停止)")
+                        try:
+                            updater.wait_for_crash_watch()
+                        except KeyboardInterrupt:
+                            print()
+                        for message, rollback_result in crashed:
+                            print(message)
+                            if rollback_result:
+                                print(f"自动回滚: {rollback_result.message}")
+                            return 1
                 else:
                     print("更新成功完成")
                 return 0
@@ -167,6 +837,8 @@ def main():
                 print(f"更新失败: {result.message}")
                 if result.error_code:
                     print(f"错误代码: {result.error_code}")
+                if result.error_code == "ASSET_TOO_LARGE":
+                    print("使用 --ignore-size-limit 仍然下载")
                 return 1
 
         # No action specified, show help
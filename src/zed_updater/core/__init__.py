@@ -21,9 +21,11 @@ __all__ = [
     'InstallationError',
     'ValidationError',
     'GitHubAPIError',
+    'RateLimitError',
     'ChecksumError',
     'ProcessError',
     'PermissionError',
+    'ElevationError',
     'TimeoutError',
     'FileOperationError',
     'SchedulerError'
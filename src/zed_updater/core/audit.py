@@ -0,0 +1,93 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Audit log of state-changing actions for Zed Updater
+"""
+
+import getpass
+import json
+import platform
+import threading
+from dataclasses import dataclass, asdict, field
+from datetime import datetime
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+from ..utils.logger import get_logger
+
+
+def _current_user() -> str:
+    try:
+        return getpass.getuser()
+    except Exception:
+        # No login name in a service or container environment
+        return "unknown"
+
+
+@dataclass
+class AuditEntry:
+    """One action that changed the installation or the configuration"""
+    action: str  # config_change / install / rollback / recover / delete_backup / crash
+    success: bool
+    details: Dict[str, Any] = field(default_factory=dict)
+    user: str = field(default_factory=_current_user)
+    host: str = field(default_factory=platform.node)
+    timestamp: str = field(default_factory=lambda: datetime.now().isoformat(timespec='seconds'))
+
+
+class AuditLog:
+    """Who changed what and when, stored as JSON
+
+    Unlike the update history it also covers config changes and deleted
+    backups, and keeps more entries since managed machines are expected
+    to read it.
+    """
+
+    MAX_ENTRIES = 1000
+
+    def __init__(self, audit_file: Path):
+        self.logger = get_logger(__name__)
+        self.audit_file = Path(audit_file)
+        self._lock = threading.Lock()
+
+    def _load(self) -> List[dict]:
+        if not self.audit_file.exists():
+            return []
+        try:
+            with open(self.audit_file, 'r', encoding='utf-8') as f:
+                data = json.load(f)
+            return data if isinstance(data, list) else []
+        except (json.JSONDecodeError, OSError) as e:
+            self.logger.warning(f"读取审计日志失败: {e}")
+            return []
+
+    def record(self, entry: AuditEntry) -> None:
+        """Append an entry, dropping the oldest beyond MAX_ENTRIES"""
+        with self._lock:
+            entries = self._load()
+            entries.append(asdict(entry))
+            try:
+                self.audit_file.parent.mkdir(parents=True, exist_ok=True)
+                tmp_file = self.audit_file.with_suffix('.tmp')
+                with open(tmp_file, 'w', encoding='utf-8') as f:
+                    json.dump(entries[-self.MAX_ENTRIES:], f, indent=2, ensure_ascii=False, default=str)
+                tmp_file.replace(self.audit_file)
+            except OSError as e:
+                self.logger.warning(f"保存审计日志失败: {e}")
+
+    def get_entries(self, limit: Optional[int] = None, action: Optional[str] = None) -> List[AuditEntry]:
+        """Get entries, newest first, optionally only those of one action"""
+        with self._lock:
+            entries = self._load()
+
+        result = []
+        for data in reversed(entries):
+            if action and data.get('action') != action:
+                continue
+            try:
+                result.append(AuditEntry(**data))
+            except TypeError:
+                continue
+            if limit and len(result) >= limit:
+                break
+        return result
@@ -4,43 +4,152 @@
 Simplified configuration management for Zed Updater
 """
 
+import glob
 import json
 import os
+import shutil
+import platform
+import threading
 from pathlib import Path
-from typing import Dict, Any, Optional, Union
-from dataclasses import dataclass, asdict
+from typing import Dict, Any, Callable, List, Optional, Tuple, Union
+from dataclasses import dataclass, asdict, field, replace as replace_fields
+from .audit import AuditLog, AuditEntry
 from ..utils.logger import get_logger
+from ..utils.credentials import CredentialStore
+from ..utils.paths import get_data_root, get_data_path, render_path, glob_path, today, default_install_path
+
+RELEASE_CHANNELS = ('stable', 'prerelease', 'nightly')
+RELEASE_SOURCES = ('github', 'gitlab', 'gitea', 'manifest')
+
+# Settings left out of exports unless asked for
+SECRET_FIELDS = ('github_token', 'release_source_token')
+REDACTED = "<redacted>"
+KEYRING_MARKER = "<keyring>"  # Stored in place of a token kept in the credential store
+
+# Bumped whenever stored settings are renamed, split or reinterpreted.
+# Files written before config_version existed are version 1.
+CONFIG_VERSION = 2
+
+
+def _migrate_1(data: Dict[str, Any]) -> Dict[str, Any]:
+    # D:\Zed.exe used to be the default on every OS
+    if platform.system() != "Windows" and data.get('zed_install_path') == r"D:\Zed.exe":
+        data['zed_install_path'] = str(default_install_path())
+    return data
+
+
+# MIGRATIONS[n] upgrades a config of version n to n + 1
+MIGRATIONS: Dict[int, Callable[[Dict[str, Any]], Dict[str, Any]]] = {
+    1: _migrate_1,
+}
+
+
+def migrate_config_data(data: Dict[str, Any]) -> Tuple[Dict[str, Any], int]:
+    """Upgrade stored config data to CONFIG_VERSION
+
+    Returns:
+        The upgraded data and the version it was stored as. Data from a
+        newer version is returned unchanged.
+    """
+    stored_version = data.get('config_version', 1)
+    if not isinstance(stored_version, int) or stored_version < 1:
+        stored_version = 1
+    data = dict(data)
+    for version in range(stored_version, CONFIG_VERSION):
+        data = MIGRATIONS[version](data)
+    if stored_version <= CONFIG_VERSION:
+        data['config_version'] = CONFIG_VERSION
+    return data, stored_version
 
 
 @dataclass
 class ConfigData:
     """Configuration data structure"""
+    config_version: int = CONFIG_VERSION  # Format of the stored file, see MIGRATIONS
+
     # Basic settings
-    zed_install_path: str = r"D:\Zed.exe"
-    github_repo: str = "TC999/zed-loc"
+    zed_install_path: str = field(default_factory=lambda: str(default_install_path()))
+    extra_install_paths: List[str] = field(default_factory=list)  # More Zed copies updated with the same download
+    github_repo: str = "TC999/zed-loc"  # owner/repo, or the project path for other sources
+    github_token: str = ""  # Falls back to the GITHUB_TOKEN environment variable
+    release_source: str = "github"  # github / gitlab / gitea (also Forgejo) / manifest
+    release_source_url: str = ""  # GitHub Enterprise / GitLab / Gitea instance, or the manifest URL
+    release_source_token: str = ""  # Token for sources other than GitHub
+    fallback_repos: List[str] = field(default_factory=list)  # GitHub repos asked when the source has nothing newer
+    release_channel: str = "stable"  # stable / prerelease / nightly
+    release_tag_pattern: str = ""  # Glob such as "v*-win", empty to accept every tag
+    ignored_release_tags: List[str] = field(default_factory=list)
+    ignore_draft_releases: bool = True
+    asset_platform: str = ""  # windows / linux / macos, empty to detect
+    asset_arch: str = ""  # x86_64 / aarch64, empty to detect
+    asset_pattern: str = ""  # Regular expression the asset name must match, empty to accept any
 
     # Update settings
     auto_check_enabled: bool = True
     check_interval_hours: int = 24
+    check_time: str = ""  # Daily check time as HH:MM, empty to check every check_interval_hours
+    adaptive_check_interval: bool = True  # Check less often after failures or near the rate limit
     check_on_startup: bool = True
     auto_download: bool = True
     auto_install: bool = False
+    prefetch_updates: bool = False  # Scheduled checks download but don't install
+    delta_updates: bool = True  # Apply .patch assets when bsdiff4 is installed
+    archive_binary_path: str = ""  # Path of Zed inside zip/tar.gz assets, found by name if empty
+    install_mode: str = "auto"  # auto / portable / installer, auto runs MSI and setup assets as installers
+    installer_args: List[str] = field(default_factory=list)  # Silent flags, empty for /quiet (MSI) or /S
+    installer_timeout: int = 600
     auto_start_after_update: bool = True
+    crash_watchdog_seconds: int = 0  # A failing exit this soon after the restart marks a bad update, 0 disables
+    rollback_on_crash: bool = False  # Roll back to the newest backup when the watchdog fires
+    install_on_exit: bool = False  # Wait for Zed to exit instead of closing it
+    install_staged_on_exit: bool = False  # Install a prefetched update as soon as Zed exits
+    zed_close_timeout: int = 10  # Seconds Zed gets to exit before it is killed
+    zed_force_kill: bool = True  # Kill Zed after zed_close_timeout, off leaves it running
+    zed_launch_args: List[str] = field(default_factory=list)  # Arguments Zed is started with
+    zed_launch_dir: str = ""  # Working directory of a started Zed, empty for the home directory
+    zed_launch_env: List[str] = field(default_factory=list)  # Extra KEY=VALUE environment variables for Zed
+    zed_launch_unelevated: bool = True  # Start Zed as the desktop user when the updater runs elevated
+    # Named launch settings, e.g. a preview build with its own user data directory
+    zed_launch_profiles: Dict[str, Dict[str, Any]] = field(default_factory=dict)
 
     # Backup settings
     backup_enabled: bool = True
     backup_count: int = 3
+    backup_dedup: bool = True  # Skip the backup when the binary equals the newest one
+    backup_zed_config: bool = False  # Also save settings.json, keymaps and themes
+    backup_zed_extensions: bool = False  # Also save installed extensions
+    backup_mirror: str = ""  # Second copy of each backup: a directory, UNC path or s3://bucket/prefix
+    backup_mirror_endpoint: str = ""  # S3-compatible endpoint URL, empty for AWS
+    backup_dir: str = ""  # Empty for <data root>/backups, supports {version} {date} {channel} {app}
+
+    # Download settings
+    download_dir: str = ""  # Empty for <data root>/downloads, same variables as backup_dir
+    temp_dir: str = ""  # Scratch space for extracting dry runs and restores, empty for the system temp directory
 
     # UI settings
     minimize_to_tray: bool = True
     notification_enabled: bool = True
     language: str = "zh_CN"
+    preferred_language: str = ""  # Localized asset to install, empty to follow language
+    language_fallbacks: List[str] = field(default_factory=lambda: ["en"])  # Tried in order after preferred_language
+    store_tokens_in_keyring: bool = True  # Keep tokens in the OS credential store when keyring is installed
 
     # Network settings
     download_timeout: int = 300
+    max_asset_size_mb: int = 0  # Refuse larger downloads, 0 for no limit
     retry_count: int = 3
     proxy_enabled: bool = False
     proxy_url: str = ""
+    download_mirror: str = ""  # ghproxy-style prefix for github.com downloads, "auto" to pick the fastest
+    download_mirror_candidates: List[str] = field(default_factory=list)  # Mirrors tried by "auto", empty for built-in
+
+    # Logging settings
+    log_level: str = "INFO"  # --log-level takes precedence
+    log_format: str = "text"  # "text" or "json", one object per line
+    log_max_size_mb: int = 10  # Rotate the log file at this size, 0 never rotates
+    log_backup_count: int = 5  # Rotated files to keep
+    log_max_age_days: int = 0  # Delete rotated files older than this, 0 to keep them
+    metrics_file: str = ""  # Also write Prometheus metrics here (node_exporter textfile collector), empty to skip
 
 
 class ConfigManager:
@@ -50,10 +159,28 @@ class ConfigManager:
 
     def __init__(self, config_file: Optional[str] = None):
         self.logger = get_logger(__name__)
-        self.config_file = Path(config_file or self.DEFAULT_CONFIG_FILE)
+        self.config_file = Path(config_file) if config_file else self._default_config_file()
         self._config = ConfigData()
+        self.load_error: Optional[str] = None  # Why the config file could not be loaded
+        self._mtime: Optional[int] = None  # Of the file as last loaded or saved
+        self._change_listeners: List[Callable[[List[str]], None]] = []
+        # Held while the configuration is replaced and saved
+        self._lock = threading.RLock()
+        # Keys this version doesn't know, written back so that nothing is lost
+        self._extra: Dict[str, Any] = {}
+        self.credentials = CredentialStore()
+        self.audit = AuditLog(get_data_path('audit'))
         self._load_config()
 
+    def _default_config_file(self) -> Path:
+        """Config in the data root, or a legacy one in the working directory"""
+        config_file = get_data_path('config')
+        legacy_file = Path(self.DEFAULT_CONFIG_FILE)
+        if not config_file.exists() and legacy_file.exists():
+            self.logger.info(f"使用旧位置的配置文件 {legacy_file.resolve()}，可运行 --migrate-data 迁移")
+            return legacy_file
+        return config_file
+
     def _load_config(self) -> None:
         """Load configuration from file"""
         try:
@@ -65,29 +192,201 @@ class ConfigManager:
             with open(self.config_file, 'r', encoding='utf-8') as f:
                 data = json.load(f)
 
-            # Update config object
-            for key, value in data.items():
-                if hasattr(self._config, key):
-                    setattr(self._config, key, value)
-
+            data, stored_version = migrate_config_data(data)
+            self._config, self._extra = self._from_data(data)
+            self._mtime = self._file_mtime()
             self.logger.info("配置文件加载成功")
 
+            if self._use_keyring() and any(data.get(key) not in ('', None, KEYRING_MARKER)
+                                           for key in SECRET_FIELDS):
+                if self._save_config():
+                    self.logger.info("访问令牌已从配置文件移到系统凭据存储")
+
+            if stored_version < CONFIG_VERSION:
+                self._upgrade_file(stored_version)
+            elif stored_version > CONFIG_VERSION:
+                self.logger.warning(f"配置文件版本 {stored_version} 比本程序支持的 {CONFIG_VERSION} 新，"
+                                    f"未知的设置会原样保留")
+
         except (json.JSONDecodeError, FileNotFoundError) as e:
+            self.load_error = str(e)
             self.logger.error(f"加载配置文件失败: {e}")
         except Exception as e:
+            self.load_error = str(e)
             self.logger.error(f"未知错误: {e}")
 
+    def _from_data(self, data: Dict[str, Any]) -> Tuple[ConfigData, Dict[str, Any]]:
+        """Config object for stored data, and the keys it has no field for
+
+        Tokens kept in the credential store are read back from it.
+        """
+        config = ConfigData()
+        extra = {}
+        invalid = self._directory_errors(data)
+        for key, value in data.items():
+            if key in invalid:
+                self.logger.warning(f"{invalid[key]}，使用默认目录")
+            elif hasattr(config, key):
+                setattr(config, key, value)
+            else:
+                extra[key] = value
+        for key in SECRET_FIELDS:
+            if getattr(config, key) == KEYRING_MARKER:
+                secret = self.credentials.get(key)
+                if secret is None:
+                    self.logger.warning(f"系统凭据存储中找不到 {key}，请重新设置")
+                setattr(config, key, secret or "")
+        return config, extra
+
+    def _directory_errors(self, values: Dict[str, Any]) -> Dict[str, str]:
+        """Why directory settings in values are unusable, keyed by setting
+
+        Checked on every way into the configuration, since cleanup and
+        migration act on whatever these settings point at.
+        """
+        # config_schema imports this module
+        from .config_schema import FIELD_INFO, check_value
+        errors = {}
+        for key, info in FIELD_INFO.items():
+            if info.get('format') != 'directory' or key not in values:
+                continue
+            value = values[key]
+            error = check_value(key, value) if isinstance(value, str) else f"{key} 应为字符串"
+            if error:
+                errors[key] = error
+        return errors
+
+    def _use_keyring(self) -> bool:
+        return self._config.store_tokens_in_keyring and self.credentials.available
+
+    def _stored_secrets(self, config_dict: Dict[str, Any]) -> Dict[str, Any]:
+        """Move tokens into the credential store, leaving markers in config_dict"""
+        if not self._use_keyring():
+            return config_dict
+        for key in SECRET_FIELDS:
+            if not config_dict.get(key):
+                self.credentials.delete(key)
+            elif self.credentials.set(key, config_dict[key]):
+                config_dict[key] = KEYRING_MARKER
+        return config_dict
+
+    def _upgrade_file(self, stored_version: int) -> None:
+        """Rewrite a migrated config file, keeping the old one next to it"""
+        backup_file = self.config_file.with_name(f"{self.config_file.name}.v{stored_version}.bak")
+        try:
+            shutil.copy2(self.config_file, backup_file)
+        except OSError as e:
+            self.logger.warning(f"备份旧版本配置文件失败: {e}")
+        if self._save_config():
+            self.logger.info(f"配置文件已从版本 {stored_version} 升级到 {CONFIG_VERSION}，原文件保存为 {backup_file.name}")
+
     def _save_config(self) -> bool:
         """Save configuration to file"""
+        with self._lock:
+            try:
+                config_dict = self._stored_secrets(dict(self._extra, **asdict(self._config)))
+                self.config_file.parent.mkdir(parents=True, exist_ok=True)
+                # Write aside and swap in, a crash mid-write must not lose the config
+                tmp_file = self.config_file.with_suffix('.tmp')
+                with open(tmp_file, 'w', encoding='utf-8') as f:
+                    json.dump(config_dict, f, indent=2, ensure_ascii=False)
+                tmp_file.replace(self.config_file)
+                self._mtime = self._file_mtime()
+                self.logger.info("配置文件保存成功")
+                return True
+            except Exception as e:
+                self.logger.error(f"保存配置文件失败: {e}")
+                return False
+
+    def _file_mtime(self) -> Optional[int]:
         try:
-            config_dict = asdict(self._config)
-            with open(self.config_file, 'w', encoding='utf-8') as f:
-                json.dump(config_dict, f, indent=2, ensure_ascii=False)
-            self.logger.info("配置文件保存成功")
-            return True
-        except Exception as e:
-            self.logger.error(f"保存配置文件失败: {e}")
-            return False
+            return self.config_file.stat().st_mtime_ns
+        except OSError:
+            return None
+
+    def add_change_listener(self, callback: Callable[[List[str]], None]) -> None:
+        """Call callback with the changed keys whenever the configuration changes
+
+        Runs in the thread that made the change, after it was saved.
+        """
+        with self._lock:
+            if callback not in self._change_listeners:
+                self._change_listeners.append(callback)
+
+    def remove_change_listener(self, callback: Callable[[List[str]], None]) -> None:
+        """Remove a change listener"""
+        with self._lock:
+            if callback in self._change_listeners:
+                self._change_listeners.remove(callback)
+
+    def _notify(self, changed: List[str]) -> None:
+        with self._lock:
+            listeners = list(self._change_listeners)
+        for callback in listeners:
+            try:
+                callback(changed)
+            except Exception as e:
+                self.logger.error(f"Config change listener failed: {e}")
+
+    def _commit(self, build: Callable[[ConfigData], ConfigData],
+                extra: Optional[Dict[str, Any]] = None, save: bool = True) -> bool:
+        """Swap in the configuration build makes from the current one,
+        save it and notify the listeners
+
+        ConfigData objects are never modified once in use, so readers in
+        other threads always see one consistent configuration.
+        """
+        with self._lock:
+            before = asdict(self._config)
+            config = build(self._config)
+            self._config = config
+            if extra is not None:
+                self._extra = extra
+            saved = self._save_config() if save else True
+            changed = [key for key, value in asdict(config).items() if before.get(key) != value]
+        if changed and save:
+            self.audit.record(AuditEntry(action="config_change", success=saved, details={
+                key: {'old': self._shown(key, before.get(key)), 'new': self._shown(key, getattr(config, key))}
+                for key in changed
+            }))
+        if changed:
+            self._notify(changed)
+        return saved
+
+    def _shown(self, key: str, value: Any) -> Any:
+        """value as written to logs and the audit log"""
+        return REDACTED if key in SECRET_FIELDS and value else value
+
+    def reload_if_changed(self) -> List[str]:
+        """Pick up edits made to the config file by another program
+
+        The CLI (--set) or a text editor may change the file while the
+        GUI runs. An unreadable file keeps the current configuration.
+
+        Returns:
+            The keys whose value changed, listeners are told about them
+        """
+        if self._file_mtime() == self._mtime:
+            return []
+
+        try:
+            with open(self.config_file, 'r', encoding='utf-8') as f:
+                data = json.load(f)
+            if not isinstance(data, dict):
+                raise ValueError("配置文件内容不是 JSON 对象")
+        except (OSError, ValueError) as e:
+            # Editors may save in several steps, the next change is tried again
+            self.logger.warning(f"重新加载配置文件失败，保留当前配置: {e}")
+            return []
+
+        self._mtime = self._file_mtime()
+        before = asdict(self._config)
+        fresh, extra = self._from_data(migrate_config_data(data)[0])
+        changed = [key for key, value in asdict(fresh).items() if before.get(key) != value]
+        if changed:
+            self.logger.info(f"配置文件已在外部修改，重新加载: {', '.join(changed)}")
+        self._commit(lambda current: fresh, extra, save=False)
+        return changed
 
     def get(self, key: str, default: Any = None) -> Any:
         """Get configuration value"""
@@ -95,36 +394,269 @@ class ConfigManager:
 
     def set(self, key: str, value: Any) -> bool:
         """Set configuration value"""
-        if hasattr(self._config, key):
-            setattr(self._config, key, value)
-            return self._save_config()
-        return False
+        if not hasattr(self._config, key):
+            return False
+        error = self._directory_errors({key: value}).get(key)
+        if error:
+            self.logger.error(error)
+            return False
+        return self._commit(lambda current: replace_fields(current, **{key: value}))
 
     def update(self, updates: Dict[str, Any]) -> bool:
-        """Update multiple configuration values"""
+        """Merge values into the configuration
+
+        Fields missing from updates keep their current value. Unknown keys
+        are skipped with a warning, invalid directories with an error and
+        a False result.
+        """
+        known = {}
+        invalid = self._directory_errors(updates)
         for key, value in updates.items():
-            if hasattr(self._config, key):
-                setattr(self._config, key, value)
-        return self._save_config()
+            if key in invalid:
+                self.logger.error(invalid[key])
+            elif hasattr(self._config, key):
+                known[key] = value
+            else:
+                self.logger.warning(f"忽略未知配置项: {key}")
+        saved = self._commit(lambda current: replace_fields(current, **known))
+        return saved and not invalid
+
+    def replace(self, data: Dict[str, Any]) -> bool:
+        """Replace the whole configuration
+
+        Unlike update, fields missing from data are reset to their
+        defaults, not kept. So are invalid directories.
+        """
+        invalid = self._directory_errors(data)
+        for error in invalid.values():
+            self.logger.error(error)
+        known = {key: value for key, value in data.items()
+                 if hasattr(self._config, key) and key not in invalid}
+        saved = self._commit(lambda current: ConfigData(**known))
+        return saved and not invalid
+
+    def reset(self, keys: Optional[List[str]] = None) -> bool:
+        """Restore the defaults for this platform
+
+        Without keys every setting is reset, and the previous file is kept
+        as config.json.bak first.
+
+        Raises:
+            KeyError: For a key that is not a setting
+        """
+        defaults = ConfigData()
+        unknown = [key for key in keys or [] if not hasattr(defaults, key)]
+        if unknown:
+            raise KeyError(unknown[0])
+
+        if keys:
+            return self._commit(lambda current: replace_fields(
+                current, **{key: getattr(defaults, key) for key in keys}))
+
+        try:
+            if self.config_file.exists():
+                shutil.copy2(self.config_file, self.config_file.with_name(f"{self.config_file.name}.bak"))
+        except OSError as e:
+            self.logger.warning(f"备份配置文件失败: {e}")
+        return self._commit(lambda current: defaults)
+
+    def export_config(self, include_secrets: bool = False) -> Dict[str, Any]:
+        """Configuration to copy to another machine
+
+        Tokens are replaced with REDACTED unless include_secrets is set;
+        import_config keeps the local value for those.
+        """
+        data = dict(self._extra, **asdict(self._config))
+        if not include_secrets:
+            for key in SECRET_FIELDS:
+                if data.get(key):
+                    data[key] = REDACTED
+        return data
+
+    def import_config(self, data: Dict[str, Any]) -> bool:
+        """Replace the configuration with an exported one
+
+        Older exports are migrated first. Redacted tokens keep their
+        current value, everything else not in data gets its default.
+        """
+        data, _ = migrate_config_data(data)
+        for key in SECRET_FIELDS:
+            if data.get(key) == REDACTED:
+                data[key] = getattr(self._config, key)
+        config, extra = self._from_data(data)
+        return self._commit(lambda current: config, extra)
 
     def get_all(self) -> Dict[str, Any]:
         """Get all configuration values"""
         return asdict(self._config)
 
-    def get_backup_dir(self) -> Path:
-        """Get backup directory path"""
-        zed_path = Path(self._config.zed_install_path)
-        return zed_path.parent / "backups"
+    def get_github_token(self) -> str:
+        """Get GitHub token from config or the GITHUB_TOKEN environment variable"""
+        return self._config.github_token or os.environ.get('GITHUB_TOKEN', '')
+
+    def get_release_source(self) -> str:
+        """Get the release source type, github if the configured one is unknown"""
+        source = (self._config.release_source or "github").lower()
+        if source not in RELEASE_SOURCES:
+            self.logger.warning(f"未知的发布来源 '{self._config.release_source}'，使用 github")
+            return "github"
+        return source
+
+    def get_source_token(self) -> str:
+        """Token for the release source, release_source_token or e.g. GITLAB_TOKEN / GITEA_TOKEN"""
+        source = self.get_release_source()
+        if source == "github":
+            return self.get_github_token()
+        return self._config.release_source_token or os.environ.get(f"{source.upper()}_TOKEN", '')
+
+    def get_release_channel(self) -> str:
+        """Get the release channel, stable if the configured one is unknown"""
+        channel = (self._config.release_channel or "stable").lower()
+        if channel not in RELEASE_CHANNELS:
+            self.logger.warning(f"未知的发布渠道 '{self._config.release_channel}'，使用 stable")
+            return "stable"
+        return channel
+
+    def get_install_paths(self) -> List[Path]:
+        """zed_install_path followed by extra_install_paths, without duplicates"""
+        paths = []
+        seen = set()
+        for path in [self._config.zed_install_path] + list(self._config.extra_install_paths or []):
+            key = os.path.normcase(os.path.abspath(path)) if path else None
+            if key and key not in seen:
+                seen.add(key)
+                paths.append(Path(path))
+        return paths
+
+    def get_asset_languages(self) -> List[str]:
+        """Languages to pick localized release assets by, most preferred first"""
+        languages = []
+        for language in [self._config.preferred_language or self._config.language] + \
+                list(self._config.language_fallbacks or []):
+            if language and language.lower().replace('_', '-') not in \
+                    [known.lower().replace('_', '-') for known in languages]:
+                languages.append(language)
+        return languages
+
+    def get_data_root(self) -> Path:
+        """Get the directory all updater data lives under"""
+        return get_data_root()
+
+    def _render_dir(self, template: str, default: str, version: Optional[str]) -> Path:
+        """Render a configured directory, see utils.paths.render_path
+
+        Without a version the date is unknown too, so the result is the
+        directory that contains the directories of all versions and dates.
+        """
+        if not template:
+            return get_data_path(default)
+        try:
+            return render_path(template, {
+                'version': version,
+                'date': today() if version is not None else None,
+                'channel': self.get_release_channel(),
+                'app': 'zed',
+            })
+        except ValueError as e:
+            self.logger.warning(f"目录模板无效 '{template}': {e}，使用默认目录")
+            return get_data_path(default)
+
+    def _rendered_dirs(self, template: str, default: str) -> List[Path]:
+        """Existing directories a configured directory renders to, see utils.paths.glob_path"""
+        if template:
+            try:
+                pattern = glob_path(template, {
+                    'version': None, 'date': None, 'channel': self.get_release_channel(), 'app': 'zed'
+                })
+                return sorted(Path(path) for path in glob.glob(pattern) if os.path.isdir(path))
+            except ValueError:
+                pass
+        default_dir = get_data_path(default)
+        return [default_dir] if default_dir.is_dir() else []
+
+    def get_backup_dir(self, version: Optional[str] = None) -> Path:
+        """Get backup directory path, for the given Zed version if set"""
+        return self._render_dir(self._config.backup_dir, 'backups', version)
+
+    def get_download_dir(self, version: Optional[str] = None) -> Path:
+        """Directory downloads are kept in (download_dir), for the given update version if set"""
+        return self._render_dir(self._config.download_dir, 'downloads', version)
+
+    def get_backup_dirs(self) -> List[Path]:
+        """Existing backup directories of all versions and dates
+
+        Unlike get_backup_dir() without a version, this never returns a
+        parent of the rendered directories, so it is safe to clean up.
+        """
+        return self._rendered_dirs(self._config.backup_dir, 'backups')
+
+    def get_download_dirs(self) -> List[Path]:
+        """Existing download directories of all versions and dates, see get_backup_dirs"""
+        return self._rendered_dirs(self._config.download_dir, 'downloads')
+
+    def get_temp_dir(self) -> Optional[Path]:
+        """Directory for short-lived files (temp_dir), None for the system default"""
+        if not self._config.temp_dir:
+            return None
+        try:
+            return render_path(self._config.temp_dir, {
+                'version': None, 'date': None, 'channel': self.get_release_channel(), 'app': 'zed'
+            })
+        except ValueError as e:
+            self.logger.warning(f"目录模板无效 '{self._config.temp_dir}': {e}，使用系统临时目录")
+            return None
+
+    def get_history_file(self) -> Path:
+        """Get update history file path"""
+        return get_data_path('history')
+
+    def get_journal_file(self) -> Path:
+        """Get install journal file path"""
+        return get_data_path('journal')
+
+    def get_metrics_file(self) -> Path:
+        """Get the file keeping the Prometheus counters"""
+        return get_data_path('metrics')
+
+    def get_mirror_status_file(self) -> Path:
+        """Get the file recording the last backup mirror run"""
+        return get_data_path('mirror_status')
+
+    def get_backup_stats_file(self) -> Path:
+        """Get the file counting backups skipped as duplicates"""
+        return get_data_path('backup_stats')
+
+    def get_release_cache_file(self) -> Path:
+        """Get the file caching GitHub release responses by ETag"""
+        return get_data_path('release_cache')
+
+    def get_crash_log_dir(self) -> Path:
+        """Get directory for collected Zed crash logs"""
+        return get_data_path('crash_logs')
+
+    def get_log_dir(self) -> Path:
+        """Get log directory path"""
+        return get_data_path('logs')
 
-    def get_temp_dir(self) -> Path:
-        """Get temporary directory path"""
-        return Path.home() / ".zed_updater" / "temp"
+    def get_legacy_locations(self) -> Dict[str, Path]:
+        """Where earlier versions kept their data, keyed like DATA_LAYOUT"""
+        legacy_root = Path.home() / ".zed_updater"
+        return {
+            'config': Path(self.DEFAULT_CONFIG_FILE).resolve(),
+            'history': legacy_root / "history.json",
+            'downloads': legacy_root / "temp",
+            'backups': Path(self._config.zed_install_path).parent / "backups",
+            'crash_logs': legacy_root / "crash_logs",
+        }
 
     def ensure_directories(self) -> None:
         """Ensure all required directories exist"""
         try:
             self.get_backup_dir().mkdir(parents=True, exist_ok=True)
-            self.get_temp_dir().mkdir(parents=True, exist_ok=True)
+            self.get_download_dir().mkdir(parents=True, exist_ok=True)
+            temp_dir = self.get_temp_dir()
+            if temp_dir:
+                temp_dir.mkdir(parents=True, exist_ok=True)
         except Exception as e:
             self.logger.warning(f"创建目录失败: {e}")
 
@@ -0,0 +1,353 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Description of the configuration fields for generated settings forms
+"""
+
+import re
+from dataclasses import fields
+from pathlib import Path
+from typing import Any, Dict, List, Optional, get_args, get_origin
+
+from .config import ConfigData, RELEASE_CHANNELS, RELEASE_SOURCES, SECRET_FIELDS
+from ..utils.paths import render_path
+
+SCHEMA_LANGUAGES = ('zh_CN', 'en')
+
+# Settings a launch profile may have, with their types
+LAUNCH_PROFILE_KEYS: Dict[str, type] = {
+    'path': str,
+    'user_data_dir': str,
+    'args': list,
+    'dir': str,
+    'env': list,
+}
+
+# Per field: description per language, plus optional constraints
+# (choices, minimum, maximum, pattern, format "directory" for
+# absolute directory templates and "launch_profiles")
+FIELD_INFO: Dict[str, Dict[str, Any]] = {
+    'config_version': {
+        'description': {'zh_CN': "配置文件格式版本，由程序维护", 'en': "Format version of the file, maintained by the updater"},
+        'minimum': 1,
+    },
+    'zed_install_path': {
+        'description': {'zh_CN': "Zed 可执行文件的完整路径", 'en': "Full path of the Zed executable"},
+    },
+    'extra_install_paths': {
+        'description': {'zh_CN': "其他需要同时更新的 Zed 副本", 'en': "More Zed copies updated with the same download"},
+    },
+    'github_repo': {
+        'description': {'zh_CN': "发布所在的仓库 (owner/repo) 或项目路径",
+                        'en': "Repository (owner/repo) or project path of the releases"},
+    },
+    'github_token': {
+        'description': {'zh_CN': "GitHub 访问令牌，为空时读取 GITHUB_TOKEN",
+                        'en': "GitHub token, falls back to GITHUB_TOKEN"},
+    },
+    'release_source': {
+        'description': {'zh_CN': "发布来源", 'en': "Release source"},
+        'choices': list(RELEASE_SOURCES),
+    },
+    'release_source_url': {
+        'description': {'zh_CN': "自建实例或更新清单的地址", 'en': "Self-hosted instance or manifest URL"},
+    },
+    'release_source_token': {
+        'description': {'zh_CN': "GitLab / Gitea 访问令牌", 'en': "GitLab / Gitea token"},
+    },
+    'fallback_repos': {
+        'description': {'zh_CN': "主来源没有更新时依次检查的 GitHub 仓库",
+                        'en': "GitHub repositories asked when the source has nothing newer"},
+    },
+    'release_channel': {
+        'description': {'zh_CN': "发布渠道", 'en': "Release channel"},
+        'choices': list(RELEASE_CHANNELS),
+    },
+    'release_tag_pattern': {
+        'description': {'zh_CN': "只考虑匹配该通配符的标签，为空不限制",
+                        'en': "Only consider tags matching this glob, empty for all"},
+    },
+    'ignored_release_tags': {
+        'description': {'zh_CN': "忽略的发布标签", 'en': "Release tags to ignore"},
+    },
+    'ignore_draft_releases': {
+        'description': {'zh_CN': "忽略草稿发布", 'en': "Ignore draft releases"},
+    },
+    'asset_platform': {
+        'description': {'zh_CN': "发布文件的平台，为空时自动检测", 'en': "Platform of the release asset, empty to detect"},
+        'choices': ['', 'windows', 'linux', 'macos'],
+    },
+    'asset_arch': {
+        'description': {'zh_CN': "发布文件的架构，为空时自动检测", 'en': "Architecture of the release asset, empty to detect"},
+        'choices': ['', 'x86_64', 'aarch64'],
+    },
+    'asset_pattern': {
+        'description': {'zh_CN': "发布文件名必须匹配的正则表达式", 'en': "Regular expression the asset name must match"},
+    },
+    'auto_check_enabled': {
+        'description': {'zh_CN': "自动检查更新", 'en': "Check for updates automatically"},
+    },
+    'check_interval_hours': {
+        'description': {'zh_CN': "自动检查间隔 (小时)", 'en': "Hours between automatic checks"},
+        'minimum': 1,
+    },
+    'check_time': {
+        'description': {'zh_CN': "每天检查的时间 (HH:MM)，为空时按间隔检查",
+                        'en': "Daily check time (HH:MM), empty to use the interval"},
+        'pattern': r'^$|^([01]\d|2[0-3]):[0-5]\d$',
+    },
+    'adaptive_check_interval': {
+        'description': {'zh_CN': "失败或接近请求限额时延长检查间隔",
+                        'en': "Check less often after failures or near the rate limit"},
+    },
+    'check_on_startup': {
+        'description': {'zh_CN': "启动时检查更新", 'en': "Check for updates on startup"},
+    },
+    'auto_download': {
+        'description': {'zh_CN': "自动下载更新", 'en': "Download updates automatically"},
+    },
+    'auto_install': {
+        'description': {'zh_CN': "自动安装更新", 'en': "Install updates automatically"},
+    },
+    'prefetch_updates': {
+        'description': {'zh_CN': "计划检查只下载不安装", 'en': "Scheduled checks download but don't install"},
+    },
+    'delta_updates': {
+        'description': {'zh_CN': "优先使用增量补丁", 'en': "Prefer .patch delta updates"},
+    },
+    'archive_binary_path': {
+        'description': {'zh_CN': "压缩包中 Zed 的相对路径，为空时自动查找",
+                        'en': "Path of Zed inside archives, found by name if empty"},
+    },
+    'install_mode': {
+        'description': {'zh_CN': "安装方式", 'en': "Install mode"},
+        'choices': ['auto', 'portable', 'installer'],
+    },
+    'installer_args': {
+        'description': {'zh_CN': "安装程序的静默参数", 'en': "Silent flags for installers"},
+    },
+    'installer_timeout': {
+        'description': {'zh_CN': "等待安装程序结束的秒数", 'en': "Seconds to wait for an installer"},
+        'minimum': 1,
+    },
+    'auto_start_after_update': {
+        'description': {'zh_CN': "更新后重新启动 Zed", 'en': "Restart Zed after updating"},
+    },
+    'crash_watchdog_seconds': {
+        'description': {'zh_CN': "更新后 Zed 在此秒数内异常退出视为更新有问题，0 为关闭",
+                        'en': "Seconds after an update in which a Zed crash marks a bad update, 0 disables"},
+        'minimum': 0,
+    },
+    'rollback_on_crash': {
+        'description': {'zh_CN': "更新后 Zed 崩溃时自动回滚", 'en': "Roll back when Zed crashes after an update"},
+    },
+    'install_on_exit': {
+        'description': {'zh_CN': "等待 Zed 退出后再安装", 'en': "Wait for Zed to exit instead of closing it"},
+    },
+    'install_staged_on_exit': {
+        'description': {'zh_CN': "Zed 退出后立即安装预下载的更新", 'en': "Install a prefetched update as soon as Zed exits"},
+    },
+    'zed_close_timeout': {
+        'description': {'zh_CN': "强制结束前等待 Zed 退出的秒数", 'en': "Seconds Zed gets to exit before it is killed"},
+        'minimum': 0,
+    },
+    'zed_force_kill': {
+        'description': {'zh_CN': "超时后强制结束 Zed", 'en': "Kill Zed when it doesn't exit in time"},
+    },
+    'zed_launch_args': {
+        'description': {'zh_CN': "启动 Zed 时传入的参数", 'en': "Arguments Zed is started with"},
+    },
+    'zed_launch_dir': {
+        'description': {'zh_CN': "启动 Zed 的工作目录，为空时使用用户主目录",
+                        'en': "Working directory Zed is started in, empty for the home directory"},
+    },
+    'zed_launch_env': {
+        'description': {'zh_CN': "启动 Zed 时额外设置的环境变量 (KEY=VALUE)",
+                        'en': "Extra environment variables for Zed (KEY=VALUE)"},
+    },
+    'zed_launch_unelevated': {
+        'description': {'zh_CN': "更新程序以管理员身份运行时以普通桌面用户启动 Zed",
+                        'en': "Start Zed as the desktop user when the updater runs elevated"},
+    },
+    'zed_launch_profiles': {
+        'description': {'zh_CN': "命名的启动配置 (path、user_data_dir、args、dir、env)",
+                        'en': "Named launch settings (path, user_data_dir, args, dir, env)"},
+        'format': 'launch_profiles',
+    },
+    'backup_enabled': {
+        'description': {'zh_CN': "安装前备份", 'en': "Back up before installing"},
+    },
+    'backup_count': {
+        'description': {'zh_CN': "保留的备份数量", 'en': "Number of backups to keep"},
+        'minimum': 1,
+    },
+    'backup_dedup': {
+        'description': {'zh_CN': "与最新备份相同时跳过", 'en': "Skip backups identical to the newest one"},
+    },
+    'backup_zed_config': {
+        'description': {'zh_CN': "同时备份 Zed 配置", 'en': "Also back up the Zed settings"},
+    },
+    'backup_zed_extensions': {
+        'description': {'zh_CN': "同时备份 Zed 扩展", 'en': "Also back up the Zed extensions"},
+    },
+    'backup_mirror': {
+        'description': {'zh_CN': "备份的第二份存放位置 (目录或 s3://)",
+                        'en': "Second copy of each backup (directory or s3://)"},
+    },
+    'backup_mirror_endpoint': {
+        'description': {'zh_CN': "S3 兼容服务地址，为空时使用 AWS", 'en': "S3-compatible endpoint, empty for AWS"},
+    },
+    'backup_dir': {
+        'description': {'zh_CN': "备份目录，为空时使用数据目录", 'en': "Backup directory, empty for the data directory"},
+        'format': 'directory',
+    },
+    'download_dir': {
+        'description': {'zh_CN': "下载目录，为空时使用数据目录", 'en': "Download directory, empty for the data directory"},
+        'format': 'directory',
+    },
+    'temp_dir': {
+        'description': {'zh_CN': "解压和恢复用的临时目录，为空时使用系统临时目录",
+                        'en': "Scratch directory for extracting and restoring, empty for the system one"},
+        'format': 'directory',
+    },
+    'minimize_to_tray': {
+        'description': {'zh_CN': "最小化到托盘", 'en': "Minimize to the tray"},
+    },
+    'notification_enabled': {
+        'description': {'zh_CN': "显示通知", 'en': "Show notifications"},
+    },
+    'language': {
+        'description': {'zh_CN': "界面语言", 'en': "Interface language"},
+    },
+    'preferred_language': {
+        'description': {'zh_CN': "优先安装的语言版本，为空时跟随界面语言",
+                        'en': "Localized build to install, empty to follow the interface"},
+    },
+    'language_fallbacks': {
+        'description': {'zh_CN': "首选语言没有时依次尝试的语言", 'en': "Languages tried after the preferred one"},
+    },
+    'store_tokens_in_keyring': {
+        'description': {'zh_CN': "安装 keyring 时把访问令牌保存在系统凭据存储中",
+                        'en': "Keep tokens in the OS credential store when keyring is installed"},
+    },
+    'download_timeout': {
+        'description': {'zh_CN': "下载超时 (秒)", 'en': "Download timeout in seconds"},
+        'minimum': 1,
+    },
+    'max_asset_size_mb': {
+        'description': {'zh_CN': "下载大小上限 (MB)，0 不限制", 'en': "Largest download in MB, 0 for no limit"},
+        'minimum': 0,
+    },
+    'retry_count': {
+        'description': {'zh_CN': "下载重试次数", 'en': "Download retries"},
+        'minimum': 0,
+    },
+    'proxy_enabled': {
+        'description': {'zh_CN': "使用代理", 'en': "Use a proxy"},
+    },
+    'proxy_url': {
+        'description': {'zh_CN': "代理地址", 'en': "Proxy URL"},
+    },
+    'download_mirror': {
+        'description': {'zh_CN': "GitHub 下载加速前缀，auto 自动测速",
+                        'en': "Prefix for github.com downloads, auto picks the fastest"},
+    },
+    'download_mirror_candidates': {
+        'description': {'zh_CN': "auto 模式测速的镜像，为空时使用内置列表",
+                        'en': "Mirrors probed by auto, empty for the built-in list"},
+    },
+    'log_level': {
+        'description': {'zh_CN': "日志级别", 'en': "Log level"},
+        'choices': ['DEBUG', 'INFO', 'WARNING', 'ERROR', 'CRITICAL'],
+    },
+    'log_format': {
+        'description': {'zh_CN': "日志格式，json 时每行一个 JSON 对象", 'en': "Log format, json writes one object per line"},
+        'choices': ['text', 'json'],
+    },
+    'log_max_size_mb': {
+        'description': {'zh_CN': "日志文件达到该大小 (MB) 时轮转，0 不轮转",
+                        'en': "Rotate the log file at this size in MB, 0 never rotates"},
+        'minimum': 0,
+    },
+    'log_backup_count': {
+        'description': {'zh_CN': "保留的轮转日志文件数量", 'en': "Rotated log files to keep"},
+        'minimum': 0,
+    },
+    'log_max_age_days': {
+        'description': {'zh_CN': "删除超过该天数的轮转日志，0 不按时间删除",
+                        'en': "Delete rotated log files older than this many days, 0 to keep them"},
+        'minimum': 0,
+    },
+    'metrics_file': {
+        'description': {'zh_CN': "同时把 Prometheus 指标写入该文件，为空不写",
+                        'en': "Also write Prometheus metrics to this file, empty to skip"},
+    },
+}
+
+
+def _type_name(annotation: Any) -> str:
+    if get_origin(annotation) in (list, List):
+        return 'list'
+    if get_origin(annotation) in (dict, Dict):
+        return 'object'
+    return {bool: 'boolean', int: 'integer', float: 'number', str: 'string'}.get(annotation, 'string')
+
+
+def get_config_schema(language: str = 'zh_CN') -> List[Dict[str, Any]]:
+    """Every configuration field with its type, default and constraints
+
+    Descriptions are in language, English if it is not translated.
+    """
+    language = language if language in SCHEMA_LANGUAGES else 'en'
+    defaults = ConfigData()
+    schema = []
+    for config_field in fields(ConfigData):
+        info = FIELD_INFO.get(config_field.name, {})
+        entry = {
+            'name': config_field.name,
+            'type': _type_name(config_field.type),
+            'default': getattr(defaults, config_field.name),
+            'description': info.get('description', {}).get(language, ''),
+        }
+        if entry['type'] == 'list':
+            item_types = get_args(config_field.type)
+            entry['items'] = _type_name(item_types[0]) if item_types else 'string'
+        for key in ('choices', 'minimum', 'maximum', 'pattern', 'format'):
+            if key in info:
+                entry[key] = info[key]
+        if config_field.name in SECRET_FIELDS:
+            entry['secret'] = True
+        schema.append(entry)
+    return schema
+
+
+def check_value(name: str, value: Any) -> Optional[str]:
+    """Why value breaks the constraints of a field, None if it doesn't"""
+    info = FIELD_INFO.get(name, {})
+    if 'choices' in info and value not in info['choices']:
+        return f"{name} 只能是 {', '.join(repr(choice) for choice in info['choices'])}"
+    if 'minimum' in info and value < info['minimum']:
+        return f"{name} 不能小于 {info['minimum']}"
+    if 'maximum' in info and value > info['maximum']:
+        return f"{name} 不能大于 {info['maximum']}"
+    if 'pattern' in info and not re.match(info['pattern'], value):
+        return f"{name} 格式不正确: {value!r}"
+    if info.get('format') == 'directory' and value:
+        try:
+            path = render_path(value, {'version': '0.0.0', 'date': '2000-01-01', 'channel': 'stable', 'app': 'zed'})
+        except ValueError as e:
+            return f"{name}: {e}"
+        if not path.is_absolute():
+            return f"{name} 必须是绝对路径: {value!r}"
+        if path.is_file():
+            return f"{name} 指向的是文件: {path}"
+    if info.get('format') == 'launch_profiles':
+        for profile, settings in value.items():
+            if not isinstance(settings, dict):
+                return f"{name}: 启动配置 {profile} 应为对象"
+            for key, setting in settings.items():
+                if key not in LAUNCH_PROFILE_KEYS:
+                    return f"{name}: 启动配置 {profile} 不支持 {key}，可用 {', '.join(LAUNCH_PROFILE_KEYS)}"
+                if not isinstance(setting, LAUNCH_PROFILE_KEYS[key]):
+                    return f"{name}: 启动配置 {profile} 的 {key} 类型不正确"
+    return None
@@ -0,0 +1,171 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Move data left behind by earlier versions into the unified data root
+"""
+
+import json
+import shutil
+from dataclasses import dataclass
+from pathlib import Path
+from typing import List
+
+from .config import ConfigManager
+from ..utils.logger import get_logger
+
+
+@dataclass
+class MigrationStep:
+    """One file moved (or not) by the migration"""
+    source: Path
+    target: Path
+    status: str  # moved / skipped_exists / failed
+    message: str = ""
+
+
+class DataMigrator:
+    """Relocate scattered legacy files into ConfigManager's data layout
+
+    Nothing is overwritten: a file whose target already exists is left in
+    place and reported, so running the migration twice is harmless.
+
+    The legacy backup directory sits next to the Zed install and may be
+    shared with other programs (D:\\backups for a drive-root install), so
+    only backups and their sidecar files are taken from it.
+    """
+
+    # Backups of all install paths with their .sha256 and .config.zip sidecars
+    LEGACY_BACKUP_PATTERN = "zed_backup[_-]*"
+
+    def __init__(self, config: ConfigManager):
+        self.config = config
+        self.logger = get_logger(__name__)
+
+    def _legacy_locations(self):
+        legacy = self.config.get_legacy_locations()
+        # Only move the config that is actually in use, not a stray config.json
+        if legacy['config'] != self.config.config_file.resolve():
+            del legacy['config']
+        return legacy
+
+    def _targets(self):
+        return {
+            'config': self.config.get_data_root() / 'config.json',
+            'history': self.config.get_history_file(),
+            # Templated directories need a version, legacy files don't say which
+            'downloads': self.config.get_download_dir("unknown"),
+            'backups': self.config.get_backup_dir("unknown"),
+            'crash_logs': self.config.get_crash_log_dir(),
+        }
+
+    def pending(self) -> List[Path]:
+        """Legacy files that a migration would move"""
+        files = []
+        targets = self._targets()
+        for name, source in self._legacy_locations().items():
+            if source.resolve() == targets[name].resolve():
+                continue
+            if source.is_file():
+                files.append(source)
+            elif source.is_dir():
+                files.extend(self._legacy_files(name, source))
+        return files
+
+    def _legacy_files(self, name: str, source: Path) -> List[Path]:
+        """Files below a legacy directory that belong to the updater"""
+        if name == 'backups':
+            return sorted(path for path in source.glob(self.LEGACY_BACKUP_PATTERN) if path.is_file())
+        return sorted(path for path in source.rglob('*') if path.is_file())
+
+    def migrate(self) -> List[MigrationStep]:
+        """Move all legacy data, returns what happened to each file"""
+        steps: List[MigrationStep] = []
+        targets = self._targets()
+        # Paths recorded in the history that now live elsewhere
+        relocated = {}
+
+        for name, source in self._legacy_locations().items():
+            target = targets[name]
+            if not source.exists() or source.resolve() == target.resolve():
+                continue
+
+            if source.is_file():
+                steps.append(self._move_file(source, target))
+            else:
+                moved_from = set()
+                for path in self._legacy_files(name, source):
+                    step = self._move_file(path, target / path.relative_to(source))
+                    steps.append(step)
+                    if step.status == 'moved':
+                        moved_from.add(path.parent)
+                self._remove_emptied_dirs(moved_from, source)
+                relocated[str(source)] = str(target)
+
+        if any(step.source == self.config.config_file.resolve() and step.status == 'moved'
+               for step in steps):
+            # Keep saving to the file that was just moved
+            self.config.config_file = targets['config']
+
+        if relocated:
+            self._relocate_history_paths(relocated)
+
+        try:
+            (Path.home() / ".zed_updater").rmdir()
+        except OSError:
+            pass  # Missing or still holding files that were not moved
+
+        moved = sum(1 for step in steps if step.status == 'moved')
+        self.logger.info(f"数据迁移完成: 移动 {moved} 个文件，共 {len(steps)} 个")
+        return steps
+
+    def _move_file(self, source: Path, target: Path) -> MigrationStep:
+        source = source.resolve()
+        if target.exists():
+            return MigrationStep(source, target, 'skipped_exists', "目标已存在，保留原文件")
+
+        try:
+            target.parent.mkdir(parents=True, exist_ok=True)
+            # shutil.move falls back to copy + delete across drives
+            shutil.move(str(source), str(target))
+            self.logger.debug(f"已迁移: {source} -> {target}")
+            return MigrationStep(source, target, 'moved')
+        except OSError as e:
+            self.logger.warning(f"迁移失败 {source}: {e}")
+            return MigrationStep(source, target, 'failed', str(e))
+
+    def _remove_emptied_dirs(self, directories: set, root: Path) -> None:
+        """Remove directories up to root that moving files out of left empty"""
+        for directory in sorted(directories, key=lambda path: len(path.parts), reverse=True):
+            while True:
+                try:
+                    directory.rmdir()
+                except OSError:
+                    break  # Still holds files that were not moved, or already gone
+                if directory == root:
+                    break
+                directory = directory.parent
+
+    def _relocate_history_paths(self, relocated: dict) -> None:
+        """Point backup and crash log paths in the history at their new place"""
+        history_file = self.config.get_history_file()
+        if not history_file.exists():
+            return
+
+        def rewrite(path):
+            if not path:
+                return path
+            for old, new in relocated.items():
+                if Path(path).is_relative_to(old):
+                    return str(Path(new) / Path(path).relative_to(old))
+            return path
+
+        try:
+            with open(history_file, 'r', encoding='utf-8') as f:
+                entries = json.load(f)
+            for entry in entries:
+                entry['backup_path'] = rewrite(entry.get('backup_path'))
+                entry['crash_logs'] = [rewrite(path) for path in entry.get('crash_logs', [])]
+            with open(history_file, 'w', encoding='utf-8') as f:
+                json.dump(entries, f, indent=2, ensure_ascii=False)
+        except (OSError, ValueError, AttributeError) as e:
+            self.logger.warning(f"更新历史中的路径失败: {e}")
@@ -0,0 +1,201 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Startup self-check for Zed Updater
+"""
+
+import json
+from dataclasses import dataclass, asdict, field
+from datetime import datetime
+from typing import Any, Dict, List
+
+from .config import ConfigManager
+from .exceptions import RateLimitError
+from ..services.elevation import can_write_to, is_admin
+from ..utils.logger import get_logger
+
+
+@dataclass
+class StartupCheck:
+    """Outcome of a single startup check"""
+    name: str
+    status: str  # ok / warning / error
+    message: str = ""
+
+
+@dataclass
+class StartupReport:
+    """All startup checks of one run"""
+    checks: List[StartupCheck] = field(default_factory=list)
+    timestamp: str = field(default_factory=lambda: datetime.now().isoformat(timespec='seconds'))
+
+    @property
+    def ok(self) -> bool:
+        """True unless a check failed with an error"""
+        return all(check.status != 'error' for check in self.checks)
+
+    def to_dict(self) -> Dict[str, Any]:
+        data = asdict(self)
+        data['ok'] = self.ok
+        return data
+
+
+class StartupDiagnostics:
+    """Quick self-check run on every start
+
+    Makes "it starts but nothing works" diagnosable from the log alone:
+    the config parsed, every directory the updater writes to is writable,
+    the history store can be read, and state left behind by the last run
+    (interrupted and deferred installs, partial downloads) was picked up.
+    """
+
+    def __init__(self, config: ConfigManager, updater):
+        self.config = config
+        self.updater = updater
+        self.logger = get_logger(__name__)
+
+    def run(self) -> StartupReport:
+        """Run all checks and log the result"""
+        report = StartupReport()
+        for check in (self._check_config, self._check_directories,
+                      self._check_install_paths, self._check_history,
+                      self._check_last_run):
+            try:
+                report.checks.extend(check())
+            except Exception as e:
+                report.checks.append(StartupCheck(check.__name__.lstrip('_'), 'error', str(e)))
+
+        for check in report.checks:
+            if check.status == 'ok':
+                self.logger.debug(f"启动检查 {check.name}: {check.message}")
+            elif check.status == 'warning':
+                self.logger.warning(f"启动检查 {check.name}: {check.message}")
+            else:
+                self.logger.error(f"启动检查 {check.name}: {check.message}")
+
+        failed = sum(1 for check in report.checks if check.status == 'error')
+        if failed:
+            self.logger.error(f"启动检查发现 {failed} 个错误，可运行 --startup-report 查看详情")
+        else:
+            self.logger.info("启动检查通过")
+        return report
+
+    def check_readiness(self) -> StartupReport:
+        """Whether updates can be checked and installed right now
+
+        The config and directory checks of run(), plus a request to every
+        release source. Meant for supervisors and scripts: unlike the
+        startup checks, a release source that can't be reached counts.
+        """
+        report = StartupReport()
+        for check in (self._check_config, self._check_directories, self._check_release_sources):
+            try:
+                report.checks.extend(check())
+            except Exception as e:
+                report.checks.append(StartupCheck(check.__name__.lstrip('_'), 'error', str(e)))
+        return report
+
+    def _check_config(self) -> List[StartupCheck]:
+        if self.config.load_error:
+            return [StartupCheck('config', 'error',
+                                 f"{self.config.config_file}: {self.config.load_error}，正在使用默认设置")]
+        return [StartupCheck('config', 'ok', str(self.config.config_file))]
+
+    def _check_directories(self) -> List[StartupCheck]:
+        checks = []
+        directories = {
+            'data_root': self.config.get_data_root(),
+            'backup_dir': self.config.get_backup_dir(),
+            'download_dir': self.config.get_download_dir(),
+            'log_dir': self.config.get_log_dir(),
+        }
+        if self.config.get_temp_dir():
+            directories['temp_dir'] = self.config.get_temp_dir()
+        for name, directory in directories.items():
+            if can_write_to(directory):
+                checks.append(StartupCheck(name, 'ok', str(directory)))
+            else:
+                checks.append(StartupCheck(name, 'error', f"目录不可写: {directory}"))
+        return checks
+
+    def _check_release_sources(self) -> List[StartupCheck]:
+        checks = []
+        for source in self.updater.sources:
+            name = f"release_source:{source.describe()}"
+            try:
+                release_info = source.get_latest_release()
+            except RateLimitError as e:
+                checks.append(StartupCheck(name, 'warning', f"请求受限，{e.retry_after:.0f} 秒后可重试"))
+                continue
+            if release_info:
+                checks.append(StartupCheck(name, 'ok', f"最新版本 {release_info.version}"))
+            else:
+                checks.append(StartupCheck(name, 'warning', "无法获取发布信息"))
+        # One answering source is enough, the others are fallbacks
+        if not any(check.status == 'ok' for check in checks):
+            checks.append(StartupCheck('release_sources', 'error', "没有可用的发布来源"))
+        return checks
+
+    def _check_install_paths(self) -> List[StartupCheck]:
+        checks = []
+        for zed_path in self.config.get_install_paths():
+            name = f"install_path:{zed_path}"
+            if not zed_path.exists():
+                checks.append(StartupCheck(name, 'warning', "Zed 可执行文件不存在，将在首次更新时安装"))
+            elif not can_write_to(zed_path.parent) and not is_admin():
+                checks.append(StartupCheck(name, 'warning', "安装目录需要管理员权限，安装时会请求提升"))
+            else:
+                checks.append(StartupCheck(name, 'ok', "可写"))
+        return checks
+
+    def _check_history(self) -> List[StartupCheck]:
+        history_file = self.config.get_history_file()
+        if not history_file.exists():
+            return [StartupCheck('history', 'ok', "尚无更新历史")]
+        try:
+            with open(history_file, 'r', encoding='utf-8') as f:
+                entries = json.load(f)
+        except (OSError, ValueError) as e:
+            return [StartupCheck('history', 'error', f"无法读取 {history_file}: {e}")]
+        if not isinstance(entries, list):
+            return [StartupCheck('history', 'error', f"格式错误: {history_file}")]
+        return [StartupCheck('history', 'ok', f"{len(entries)} 条记录")]
+
+    def _check_last_run(self) -> List[StartupCheck]:
+        checks = []
+
+        for message in self.updater.recover_interrupted_installs():
+            checks.append(StartupCheck('install_journal', 'warning', message))
+
+        pending = [path for path in self.config.get_install_paths()
+                   if path.with_name(f".{path.name}.new").exists()]
+        if pending:
+            if self.updater.apply_pending_install():
+                checks.append(StartupCheck('pending_install', 'ok', "已应用上次延迟的更新"))
+            remaining = [path for path in pending if path.with_name(f".{path.name}.new").exists()]
+            if remaining:
+                checks.append(StartupCheck(
+                    'pending_install', 'warning',
+                    f"延迟的更新仍未应用: {', '.join(str(path) for path in remaining)}"
+                ))
+
+        partial = [path for download_dir in self.config.get_download_dirs()
+                   for path in download_dir.glob('zed_update_*.part')]
+        if partial:
+            checks.append(StartupCheck('partial_downloads', 'ok',
+                                       f"{len(partial)} 个未完成的下载，下次下载时继续"))
+
+        crash_logs = self.updater.collect_crash_logs()
+        if crash_logs:
+            checks.append(StartupCheck('crash_logs', 'warning',
+                                       f"检测到 {len(crash_logs)} 个新的 Zed 崩溃日志"))
+
+        mirror_status = self.updater.backup_mirror.get_status()
+        if self.updater.backup_mirror.enabled and mirror_status and not mirror_status.get('success'):
+            checks.append(StartupCheck('backup_mirror', 'warning',
+                                       f"上次同步备份到 {mirror_status.get('destination')} 失败: "
+                                       f"{mirror_status.get('error')}"))
+
+        if not checks:
+            checks.append(StartupCheck('last_run', 'ok', "没有需要恢复的状态"))
+        return checks
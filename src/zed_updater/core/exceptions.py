@@ -43,6 +43,15 @@ class GitHubAPIError(NetworkError):
         self.response_data = response_data or {}
 
 
+class RateLimitError(GitHubAPIError):
+    """GitHub primary or secondary rate limit errors"""
+    def __init__(self, message: str, retry_after: float = None, secondary: bool = False,
+                 status_code: int = None, response_data: dict = None):
+        super().__init__(message, status_code, response_data)
+        self.retry_after = retry_after
+        self.secondary = secondary
+
+
 class ChecksumError(ValidationError):
     """Checksum validation errors"""
     def __init__(self, expected: str, actual: str, file_path: str):
@@ -65,6 +74,13 @@ class PermissionError(ZedUpdaterError):
     pass
 
 
+class ElevationError(PermissionError):
+    """Administrator rights needed but unavailable or declined"""
+    def __init__(self, message: str, declined: bool = False):
+        super().__init__(message)
+        self.declined = declined
+
+
 class TimeoutError(ZedUpdaterError):
     """Timeout related errors"""
     pass
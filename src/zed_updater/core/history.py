@@ -0,0 +1,126 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Update history for Zed Updater
+"""
+
+import csv
+import io
+import json
+import threading
+from dataclasses import dataclass, asdict, field
+from datetime import datetime
+from pathlib import Path
+from typing import List, Optional
+
+from ..utils.logger import get_logger
+
+
+@dataclass
+class HistoryEntry:
+    """A single install or rollback"""
+    action: str  # install / rollback / recover / crash
+    success: bool
+    version: Optional[str] = None
+    previous_version: Optional[str] = None
+    message: str = ""
+    backup_path: Optional[str] = None
+    source: Optional[str] = None  # Release source that supplied the update
+    crash_logs: List[str] = field(default_factory=list)  # Zed crash logs seen after this entry
+    timestamp: str = field(default_factory=lambda: datetime.now().isoformat(timespec='seconds'))
+
+
+class UpdateHistory:
+    """Append-only history of installs and rollbacks, stored as JSON"""
+
+    MAX_ENTRIES = 200
+    EXPORT_FORMATS = ('csv', 'json')
+
+    def __init__(self, history_file: Path):
+        self.logger = get_logger(__name__)
+        self.history_file = Path(history_file)
+        self._lock = threading.Lock()
+
+    def _load(self) -> List[dict]:
+        if not self.history_file.exists():
+            return []
+        try:
+            with open(self.history_file, 'r', encoding='utf-8') as f:
+                data = json.load(f)
+            return data if isinstance(data, list) else []
+        except (json.JSONDecodeError, OSError) as e:
+            self.logger.warning(f"读取更新历史失败: {e}")
+            return []
+
+    def _save(self, entries: List[dict]) -> bool:
+        try:
+            self.history_file.parent.mkdir(parents=True, exist_ok=True)
+            tmp_file = self.history_file.with_suffix('.tmp')
+            with open(tmp_file, 'w', encoding='utf-8') as f:
+                json.dump(entries, f, indent=2, ensure_ascii=False)
+            tmp_file.replace(self.history_file)
+            return True
+        except OSError as e:
+            self.logger.warning(f"保存更新历史失败: {e}")
+            return False
+
+    def record(self, entry: HistoryEntry) -> None:
+        """Append an entry, dropping the oldest beyond MAX_ENTRIES"""
+        with self._lock:
+            entries = self._load()
+            entries.append(asdict(entry))
+            self._save(entries[-self.MAX_ENTRIES:])
+
+    def attach_crash_logs(self, timestamp: str, action: str, paths: List[str]) -> bool:
+        """Add crash log paths to the entry recorded at timestamp"""
+        with self._lock:
+            entries = self._load()
+            for data in reversed(entries):
+                if data.get('timestamp') == timestamp and data.get('action') == action:
+                    known = data.setdefault('crash_logs', [])
+                    known.extend(path for path in paths if path not in known)
+                    break
+            else:
+                return False
+
+            return self._save(entries)
+
+    def export(self, fmt: str = 'csv') -> str:
+        """Render the whole history as a report, oldest first
+
+        CSV has one row per entry with the HistoryEntry fields as columns;
+        crash log paths are joined with ";".
+
+        Raises:
+            ValueError: If fmt is not one of EXPORT_FORMATS
+        """
+        if fmt not in self.EXPORT_FORMATS:
+            raise ValueError(f"Unsupported export format: {fmt}")
+
+        entries = [asdict(entry) for entry in reversed(self.get_entries())]
+        if fmt == 'json':
+            return json.dumps(entries, indent=2, ensure_ascii=False)
+
+        output = io.StringIO()
+        columns = list(HistoryEntry.__dataclass_fields__)
+        writer = csv.DictWriter(output, fieldnames=columns, lineterminator='\n')
+        writer.writeheader()
+        for entry in entries:
+            entry['crash_logs'] = ';'.join(entry['crash_logs'])
+            writer.writerow(entry)
+        return output.getvalue()
+
+    def get_entries(self, limit: Optional[int] = None) -> List[HistoryEntry]:
+        """Get entries, newest first"""
+        with self._lock:
+            entries = self._load()
+
+        result = []
+        for data in reversed(entries):
+            try:
+                result.append(HistoryEntry(**data))
+            except TypeError:
+                continue
+            if limit and len(result) >= limit:
+                break
+        return result
@@ -0,0 +1,110 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Install transaction journal for Zed Updater
+"""
+
+import json
+import os
+import threading
+from dataclasses import dataclass, asdict, field
+from datetime import datetime
+from pathlib import Path
+from typing import List, Optional
+
+from ..utils.logger import get_logger
+
+
+@dataclass
+class JournalEntry:
+    """Progress of one install, written before each step takes effect"""
+    target: str
+    staged: str
+    step: str = "started"  # started / backup_created / staged / old_moved
+    staged_sha256: str = ""
+    backup: Optional[str] = None
+    aside: Optional[str] = None  # Where the running executable is being moved
+    started: str = field(default_factory=lambda: datetime.now().isoformat(timespec='seconds'))
+
+
+class InstallJournal:
+    """Durable record of installs in progress
+
+    An entry exists only while an install runs; it is removed once the
+    new file is in place or the install failed cleanly. An install
+    waiting for Zed to exit keeps its entry until the file is replaced.
+    An entry found on startup therefore means the process died
+    mid-install or before a deferred install was applied, and its step
+    tells recovery which files to trust.
+    """
+
+    STEPS = ('started', 'backup_created', 'staged', 'old_moved')
+
+    def __init__(self, journal_file: Path):
+        self.logger = get_logger(__name__)
+        self.journal_file = Path(journal_file)
+        self._lock = threading.Lock()
+
+    def _load(self) -> List[dict]:
+        if not self.journal_file.exists():
+            return []
+        try:
+            with open(self.journal_file, 'r', encoding='utf-8') as f:
+                data = json.load(f)
+            return data if isinstance(data, list) else []
+        except (json.JSONDecodeError, OSError) as e:
+            self.logger.warning(f"读取安装日志失败: {e}")
+            return []
+
+    def _save(self, entries: List[dict]) -> None:
+        if not entries:
+            try:
+                self.journal_file.unlink()
+            except FileNotFoundError:
+                pass
+            return
+
+        self.journal_file.parent.mkdir(parents=True, exist_ok=True)
+        tmp_file = self.journal_file.with_suffix('.tmp')
+        with open(tmp_file, 'w', encoding='utf-8') as f:
+            json.dump(entries, f, indent=2, ensure_ascii=False)
+            f.flush()
+            # The point of the journal is to survive a power loss
+            os.fsync(f.fileno())
+        tmp_file.replace(self.journal_file)
+
+    def begin(self, target: Path, staged: Path) -> JournalEntry:
+        """Start a transaction for an install path"""
+        entry = JournalEntry(target=str(target), staged=str(staged))
+        with self._lock:
+            entries = [data for data in self._load() if data.get('target') != entry.target]
+            entries.append(asdict(entry))
+            self._save(entries)
+        return entry
+
+    def update(self, entry: JournalEntry, **changes) -> None:
+        """Record the next step of a transaction"""
+        for key, value in changes.items():
+            setattr(entry, key, value)
+        with self._lock:
+            entries = [data for data in self._load() if data.get('target') != entry.target]
+            entries.append(asdict(entry))
+            self._save(entries)
+
+    def finish(self, entry: JournalEntry) -> None:
+        """Drop a transaction that completed or failed cleanly"""
+        with self._lock:
+            self._save([data for data in self._load() if data.get('target') != entry.target])
+
+    def pending(self) -> List[JournalEntry]:
+        """Transactions left behind by an interrupted install"""
+        with self._lock:
+            entries = self._load()
+
+        result = []
+        for data in entries:
+            try:
+                result.append(JournalEntry(**data))
+            except TypeError:
+                continue
+        return result
@@ -0,0 +1,150 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Prometheus metrics for Zed Updater
+"""
+
+import json
+import threading
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Tuple
+
+from ..utils.logger import get_logger
+
+
+class UpdateMetrics:
+    """Counters of update checks, downloads and installs
+
+    The counters are kept in a JSON file, so they keep growing across
+    CLI runs as Prometheus expects. render() produces the text
+    exposition format; written to a file it is picked up by
+    node_exporter's textfile collector.
+    """
+
+    CHECK_RESULTS = ('update_available', 'no_update', 'failed')
+    DOWNLOAD_BUCKETS = (1, 5, 15, 60, 300, 900)  # Upper bounds in seconds
+
+    def __init__(self, metrics_file: Path):
+        self.logger = get_logger(__name__)
+        self.metrics_file = Path(metrics_file)
+        self._lock = threading.Lock()
+
+    def _load(self) -> Dict[str, Any]:
+        if not self.metrics_file.exists():
+            return {}
+        try:
+            with open(self.metrics_file, 'r', encoding='utf-8') as f:
+                data = json.load(f)
+            return data if isinstance(data, dict) else {}
+        except (json.JSONDecodeError, OSError) as e:
+            self.logger.warning(f"读取统计数据失败: {e}")
+            return {}
+
+    def _save(self, data: Dict[str, Any]) -> None:
+        try:
+            self.metrics_file.parent.mkdir(parents=True, exist_ok=True)
+            tmp_file = self.metrics_file.with_suffix('.tmp')
+            with open(tmp_file, 'w', encoding='utf-8') as f:
+                json.dump(data, f, indent=2)
+            tmp_file.replace(self.metrics_file)
+        except OSError as e:
+            self.logger.warning(f"保存统计数据失败: {e}")
+
+    def _increment(self, data: Dict[str, Any], name: str, label: str, amount: float = 1) -> None:
+        counters = data.setdefault(name, {})
+        counters[label] = counters.get(label, 0) + amount
+
+    def record_check(self, result: str) -> None:
+        """Count an update check, result is one of CHECK_RESULTS"""
+        with self._lock:
+            data = self._load()
+            self._increment(data, 'checks', result)
+            self._save(data)
+
+    def record_download(self, success: bool, transferred: int = 0, seconds: float = 0.0) -> None:
+        """Count a finished or failed download with the bytes it transferred"""
+        with self._lock:
+            data = self._load()
+            self._increment(data, 'downloads', 'success' if success else 'failure')
+            data['download_bytes'] = data.get('download_bytes', 0) + transferred
+            if success:
+                durations = data.setdefault('download_seconds', {'buckets': {}, 'sum': 0.0, 'count': 0})
+                for bound in self.DOWNLOAD_BUCKETS:
+                    if seconds <= bound:
+                        self._increment(durations, 'buckets', str(bound))
+                durations['sum'] += seconds
+                durations['count'] += 1
+            self._save(data)
+
+    def record_install(self, action: str, success: bool) -> None:
+        """Count an install, rollback or recovery"""
+        with self._lock:
+            data = self._load()
+            self._increment(data, 'installs', f"{action}:{'success' if success else 'failure'}")
+            self._save(data)
+
+    def render(self, rate_limits: Optional[List[Tuple[str, Dict[str, Any]]]] = None) -> str:
+        """Metrics in the Prometheus text format
+
+        Args:
+            rate_limits: (source, status) pairs as returned by
+                ZedUpdater.get_rate_limit_status, shown as gauges
+        """
+        with self._lock:
+            data = self._load()
+        lines = [
+            "# HELP zed_updater_checks_total Update checks by result.",
+            "# TYPE zed_updater_checks_total counter",
+        ]
+        checks = data.get('checks', {})
+        for result in self.CHECK_RESULTS:
+            lines.append(f'zed_updater_checks_total{{result="{result}"}} {checks.get(result, 0)}')
+
+        lines += [
+            "# HELP zed_updater_downloads_total Downloads by result.",
+            "# TYPE zed_updater_downloads_total counter",
+        ]
+        downloads = data.get('downloads', {})
+        for result in ('success', 'failure'):
+            lines.append(f'zed_updater_downloads_total{{result="{result}"}} {downloads.get(result, 0)}')
+        lines += [
+            "# HELP zed_updater_download_bytes_total Bytes downloaded.",
+            "# TYPE zed_updater_download_bytes_total counter",
+            f"zed_updater_download_bytes_total {data.get('download_bytes', 0)}",
+        ]
+
+        durations = data.get('download_seconds', {})
+        buckets = durations.get('buckets', {})
+        lines += [
+            "# HELP zed_updater_download_duration_seconds Duration of successful downloads.",
+            "# TYPE zed_updater_download_duration_seconds histogram",
+        ]
+        for bound in self.DOWNLOAD_BUCKETS:
+            lines.append(f'zed_updater_download_duration_seconds_bucket{{le="{bound}"}} {buckets.get(str(bound), 0)}')
+        lines += [
+            f'zed_updater_download_duration_seconds_bucket{{le="+Inf"}} {durations.get("count", 0)}',
+            f"zed_updater_download_duration_seconds_sum {durations.get('sum', 0.0)}",
+            f"zed_updater_download_duration_seconds_count {durations.get('count', 0)}",
+        ]
+
+        lines += [
+            "# HELP zed_updater_installs_total Installs, rollbacks and recoveries by result.",
+            "# TYPE zed_updater_installs_total counter",
+        ]
+        for label, count in sorted(data.get('installs', {}).items()):
+            action, _, result = label.partition(':')
+            lines.append(f'zed_updater_installs_total{{action="{action}",result="{result}"}} {count}')
+
+        known = [(source, status) for source, status in rate_limits or [] if 'remaining' in status]
+        if known:
+            lines += [
+                "# HELP zed_updater_rate_limit_remaining API requests left before the source's rate limit.",
+                "# TYPE zed_updater_rate_limit_remaining gauge",
+            ]
+            for source, status in known:
+                lines.append(f'zed_updater_rate_limit_remaining{{source="{_escape(source)}"}} {status["remaining"]}')
+        return "\n".join(lines) + "\n"
+
+
+def _escape(value: str) -> str:
+    return value.replace('\\', '\\\\').replace('"', '\\"').replace('\n', '\\n')
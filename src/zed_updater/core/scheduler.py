@@ -22,10 +22,24 @@ class ScheduleStatus:
     next_run_time: Optional[datetime]
     last_run_time: Optional[datetime]
     last_result: Optional[UpdateResult]
+    effective_interval_hours: Optional[float] = None
+    consecutive_failures: int = 0
 
 
 class UpdateScheduler:
-    """Scheduler for automatic Zed updates"""
+    """Scheduler for automatic Zed updates
+
+    With adaptive_check_interval, the interval doubles for every failed
+    check in a row (up to MAX_BACKOFF_STEPS times) and once more while
+    less than RATE_LIMIT_LOW of the API requests are left. A successful
+    check restores it. Checks never run before a rate limit pause ends.
+    """
+
+    SCHEDULE_SETTINGS = frozenset({
+        'auto_check_enabled', 'check_interval_hours', 'check_time', 'adaptive_check_interval',
+    })
+    MAX_BACKOFF_STEPS = 3
+    RATE_LIMIT_LOW = 0.2  # Share of the rate limit left below which checks slow down
 
     def __init__(self, updater: ZedUpdater, config: ConfigManager):
         self.updater = updater
@@ -42,6 +56,11 @@ class UpdateScheduler:
             last_run_time=None,
             last_result=None
         )
+        config.add_change_listener(self._on_config_changed)
+
+    def _on_config_changed(self, changed: list) -> None:
+        if self.SCHEDULE_SETTINGS.intersection(changed):
+            self.update_schedule_config()
 
     def add_update_callback(self, callback: Callable[[bool, UpdateResult], None]) -> None:
         """Add callback for update events"""
@@ -110,9 +129,14 @@ class UpdateScheduler:
         self.logger.info("Forced update check initiated")
 
         try:
-            result = self.updater.check_and_update()
+            if self.config.get('prefetch_updates') and not self.config.get('auto_install'):
+                # Only stage the download, the user installs when ready
+                result = self.updater.prefetch_update()
+            else:
+                result = self.updater.check_and_update()
             self._status.last_run_time = datetime.now()
             self._status.last_result = result
+            self._status.consecutive_failures = 0 if result.success else self._status.consecutive_failures + 1
 
             # Notify callbacks
             update_available = result.success and result.version is not None
@@ -127,6 +151,7 @@ class UpdateScheduler:
                 error_code="SCHEDULE_FAILED"
             )
             self._status.last_result = error_result
+            self._status.consecutive_failures += 1
             self._notify_callbacks(False, error_result)
             return error_result
 
@@ -183,7 +208,7 @@ class UpdateScheduler:
                 self._status.next_run_time = None
                 return
 
-            interval_hours = self.config.get('check_interval_hours', 24)
+            interval_hours = self.get_effective_interval()
             check_time = self.config.get('check_time')
 
             now = datetime.now()
@@ -205,6 +230,11 @@ class UpdateScheduler:
                 # Use interval from now
                 next_run = now + timedelta(hours=interval_hours)
 
+            # Checking before the pause ends would only be skipped
+            paused_until = self._rate_limit_paused_until()
+            if paused_until and paused_until > next_run:
+                next_run = paused_until
+
             self._status.next_run_time = next_run
             self.logger.debug(f"Next scheduled run: {next_run}")
 
@@ -212,6 +242,35 @@ class UpdateScheduler:
             self.logger.error(f"Failed to calculate next run time: {e}")
             self._status.next_run_time = None
 
+    def get_effective_interval(self) -> float:
+        """Hours between checks after adapting to failures and the rate limit"""
+        base = float(self.config.get('check_interval_hours', 24))
+        steps = 0
+        if self.config.get('adaptive_check_interval'):
+            steps = self._status.consecutive_failures + (1 if self._rate_limit_low() else 0)
+        interval = base * 2 ** min(steps, self.MAX_BACKOFF_STEPS)
+
+        if interval != self._status.effective_interval_hours and self._status.effective_interval_hours:
+            self.logger.info(f"检查间隔调整为 {interval:g} 小时 (设置为 {base:g} 小时)")
+        self._status.effective_interval_hours = interval
+        return interval
+
+    def _rate_limit_low(self) -> bool:
+        """Whether a release source is close to its rate limit"""
+        for source in self.updater.sources:
+            remaining, limit = source.rate_limit.get('remaining'), source.rate_limit.get('limit')
+            # Counts from before the window reset are stale
+            if source.rate_limit.get('reset', 0) < time.time():
+                continue
+            if remaining is not None and limit and remaining < limit * self.RATE_LIMIT_LOW:
+                return True
+        return False
+
+    def _rate_limit_paused_until(self) -> Optional[datetime]:
+        """End of the latest rate limit pause of the release sources"""
+        paused = [source.get_rate_limit_status()['paused_until'] for source in self.updater.sources]
+        return max((until for until in paused if until), default=None)
+
     def get_next_run_time(self) -> Optional[datetime]:
         """Get the next scheduled run time"""
         return self._status.next_run_time
@@ -5,21 +5,45 @@ Simplified Zed Editor updater - Unified architecture
 """
 
 import os
+import re
 import shutil
+import platform
+import stat
 import hashlib
 import tempfile
 import subprocess
 import time
 import json
+import threading
+from functools import wraps
 from pathlib import Path
-from typing import Optional, Callable, Dict, Any
-from dataclasses import dataclass
+from typing import Optional, Callable, Dict, Any, List, Set, Tuple, Union
+from urllib.parse import urlparse
+from dataclasses import dataclass, field
 from datetime import datetime
 
 import requests
 import psutil
 from .config import ConfigManager
+from .audit import AuditEntry
+from .exceptions import RateLimitError, InstallationError, ElevationError
+from .history import UpdateHistory, HistoryEntry
+from .journal import InstallJournal, JournalEntry
+from .metrics import UpdateMetrics
+from ..services.github_api import GitHubAPI, ReleaseInfo, ScopedAuthSession
+from ..services.gitlab_api import GitLabAPI
+from ..services.gitea_api import GiteaAPI
+from ..services.manifest_source import ManifestSource
+from ..services.elevation import ElevationHelper, can_write_to, desktop_user, drop_privileges, is_admin
+from ..services.crash_logs import CrashLogCollector
+from ..services.zed_config import ZedConfigBackup
+from ..services.backup_mirror import BackupMirror
+from ..services.download_mirror import DownloadAccelerator
 from ..utils.logger import get_logger
+from ..utils.transfer import TransferRateTracker, format_size, format_duration
+from ..utils.file_type import validate_file_type, detect_file_type, EXECUTABLE_TYPES
+from ..utils.archive import archive_suffix, extract_archive, find_binary
+from ..utils.version import compare_versions
 
 
 @dataclass
@@ -29,39 +53,228 @@ class UpdateResult:
     message: str
     version: Optional[str] = None
     error_code: Optional[str] = None
+    download_path: Optional[Path] = None  # Set when an update was downloaded but not installed
+    install_method: Optional[str] = None  # replaced / renamed_running / scheduled / elevated
+    install_path: Optional[str] = None
+    target_results: List['UpdateResult'] = field(default_factory=list)  # Per target when installing to several
+    actions: List[str] = field(default_factory=list)  # What a dry run would do
 
 
 @dataclass
-class ReleaseInfo:
-    """Release information"""
-    version: str
-    release_date: datetime
-    download_url: str
-    description: str
-    size: int
-    sha256: Optional[str] = None
+class UpdateStatus:
+    """Installed version against the latest release"""
+    current_version: Optional[str]
+    latest_version: str
+    update_available: bool
+    release: Optional[ReleaseInfo] = None
+    versions: List[str] = field(default_factory=list)  # Releases the update skips over, newest first
+    changelog: str = ""  # Release notes of all of them, newest first
+
+
+def _set_event() -> threading.Event:
+    event = threading.Event()
+    event.set()
+    return event
+
+
+@dataclass
+class DownloadProgress:
+    """State of the current or last download
+
+    Every download has its own, so pausing one never holds up another.
+    """
+    downloaded: int = 0
+    total: int = 0
+    rate: float = 0.0  # bytes per second, rolling average
+    eta: Optional[float] = None  # seconds remaining
+    paused: bool = False  # The transfer is stopped, see pause()
+    # Set while the download may run, cleared to pause it
+    resumed: threading.Event = field(default_factory=_set_event, repr=False, compare=False)
+
+    @property
+    def percent(self) -> float:
+        return (self.downloaded / self.total) * 100 if self.total else 0.0
+
+    @property
+    def pause_requested(self) -> bool:
+        return not self.resumed.is_set()
+
+    def pause(self) -> None:
+        self.resumed.clear()
+
+    def resume(self) -> None:
+        self.resumed.set()
+
+
+@dataclass
+class LockConflict:
+    """Install target held open by another process"""
+    file_path: Path
+    processes: List[Dict[str, Any]]  # pid, name and exe of each holder
+    error: str
+    options: Tuple[str, ...] = ('retry', 'force_close', 'schedule', 'cancel')
+
+
+def critical_section(method):
+    """Mark a method that replaces files, shutdown waits for it to return"""
+    @wraps(method)
+    def wrapper(self, *args, **kwargs):
+        with self._busy:
+            self._busy_count += 1
+        try:
+            return method(self, *args, **kwargs)
+        finally:
+            with self._busy:
+                self._busy_count -= 1
+                self._busy.notify_all()
+    return wrapper
+
+
+@dataclass
+class ZedStopResult:
+    """How stop_zed ended the running Zed processes"""
+    closed: List[int] = field(default_factory=list)  # PIDs that exited when asked to
+    killed: List[int] = field(default_factory=list)  # PIDs killed after the timeout
+    running: List[int] = field(default_factory=list)  # PIDs still alive
+
+    @property
+    def success(self) -> bool:
+        return not self.running
 
 
 class ZedUpdater:
     """Simplified and unified Zed updater"""
 
+    REPLACE_RETRIES = 4
+    REPLACE_RETRY_DELAY = 0.5
+    EXIT_POLL_SECONDS = 2  # How often a wait for Zed to exit checks for shutdown
+    LOCKED_WINERRORS = (5, 32)  # ERROR_ACCESS_DENIED, ERROR_SHARING_VIOLATION
+    PIPELINE_STAGES = ('check', 'download', 'verify', 'stop', 'install', 'restart')
+    INSTALLER_MARKERS = ('setup', 'installer')
+    MSI_REBOOT_REQUIRED = 3010
+    INSTALLER_EXIT_CODES = {
+        1602: "用户取消了安装",
+        1603: "安装过程中发生严重错误",
+        1618: "另一个安装正在进行",
+        1625: "系统策略禁止此安装",
+    }
+
+    # Settings apply_config builds the sources, mirrors and proxy from
+    SOURCE_SETTINGS = frozenset({
+        'github_repo', 'github_token', 'release_source', 'release_source_url', 'release_source_token',
+        'fallback_repos', 'release_channel', 'release_tag_pattern', 'ignored_release_tags',
+        'ignore_draft_releases', 'asset_platform', 'asset_arch', 'asset_pattern', 'language',
+        'preferred_language', 'language_fallbacks', 'backup_mirror', 'backup_mirror_endpoint', 'backup_dir',
+        'download_mirror', 'download_mirror_candidates', 'proxy_enabled', 'proxy_url',
+    })
+
     def __init__(self, config: ConfigManager):
         self.config = config
         self.logger = get_logger(__name__)
-        self.session = requests.Session()
+        # Downloads may carry a source's token, see ScopedAuthSession
+        self.session = ScopedAuthSession()
         self.session.headers.update({
             'User-Agent': 'ZedUpdater/2.0',
             'Accept': 'application/vnd.github.v3+json'
         })
 
+        # The most recently started download, the one pause_download pauses
+        self._download_progress = DownloadProgress()
+        # Set once the process is going down, long operations stop at the next safe point
+        self._shutdown = threading.Event()
+        # Number of running critical sections, see critical_section
+        self._busy = threading.Condition()
+        self._busy_count = 0
+
+        self.history = UpdateHistory(config.get_history_file())
+        # Whether the last check_for_updates got no answer from any source
+        self.last_check_failed = False
+        self._exit_watchers: List[threading.Thread] = []
+        self._crash_watchers: List[threading.Thread] = []
+        # Prefetched update waiting for Zed to exit, see install_staged_on_exit
+        self._staged_exit_version: Optional[str] = None
+        self._staged_exit_lock = threading.Lock()
+        self._crash_callbacks: List[Callable[[str, Optional[UpdateResult]], None]] = []
+        # Backups currently being restored, they must not be deleted
+        self._restoring: Set[Path] = set()
+        self._restoring_lock = threading.Lock()
+        self.journal = InstallJournal(config.get_journal_file())
+        self.metrics = UpdateMetrics(config.get_metrics_file())
+        # Shared with the config, which records its own changes
+        self.audit = config.audit
+        self.zed_config = ZedConfigBackup()
+
+        self.apply_config()
+        config.add_change_listener(self._on_config_changed)
+
+    def apply_config(self) -> None:
+        """(Re)build the release sources, mirrors and proxy from the config
+
+        Called again when the config changes while running. Paths of the
+        data directory are only read at startup.
+        """
+        # The configured source first, then fallback_repos in order
+        self.source = self._create_release_source()
+        self.sources = [self.source] + [
+            GitHubAPI(repo, token=self.config.get_github_token())
+            for repo in self.config.get('fallback_repos', []) if repo
+        ]
+        for source in self.sources:
+            source.set_release_filter(
+                self.config.get('release_tag_pattern', ''),
+                self.config.get('ignored_release_tags', []),
+                self.config.get('ignore_draft_releases', True)
+            )
+            source.set_channel(self.config.get_release_channel())
+            source.set_platform(self.config.get('asset_platform', ''), self.config.get('asset_arch', ''))
+            source.set_asset_pattern(self.config.get('asset_pattern', ''))
+            source.set_language_preference(self.config.get_asset_languages())
+            source.set_cache_file(self.config.get_release_cache_file())
+
+        self.backup_mirror = BackupMirror(
+            self.config.get('backup_mirror', ''),
+            self.config.get_backup_dir(),
+            self.config.get_mirror_status_file(),
+            self.config.get('backup_mirror_endpoint', '')
+        )
+
+        self.accelerator = DownloadAccelerator(
+            self.config.get('download_mirror', ''), self.config.get('download_mirror_candidates', []), self.session
+        )
+
         # Setup proxy if configured
-        if config.get('proxy_enabled') and config.get('proxy_url'):
-            proxy_url = config.get('proxy_url')
+        self.session.proxies = {}
+        if self.config.get('proxy_enabled') and self.config.get('proxy_url'):
+            proxy_url = self.config.get('proxy_url')
             self.session.proxies = {'http': proxy_url, 'https': proxy_url}
-
-    def get_current_version(self) -> Optional[str]:
-        """Get currently installed Zed version"""
-        zed_path = self.config.get('zed_install_path')
+            for source in self.sources:
+                source.set_proxy(proxy_url)
+
+    def _on_config_changed(self, changed: List[str]) -> None:
+        if self.SOURCE_SETTINGS.intersection(changed):
+            self.logger.info("发布来源相关设置已更改，重新配置")
+            self.apply_config()
+
+    def _create_release_source(self) -> GitHubAPI:
+        """Client for the configured release_source"""
+        repo = self.config.get('github_repo', 'TC999/zed-loc')
+        source = self.config.get_release_source()
+        if source == "gitlab":
+            return GitLabAPI(repo, self.config.get('release_source_url', ''), self.config.get_source_token())
+        if source == "gitea":
+            if not self.config.get('release_source_url'):
+                self.logger.error("release_source 为 gitea 时必须设置 release_source_url")
+            return GiteaAPI(repo, self.config.get('release_source_url', ''), self.config.get_source_token())
+        if source == "manifest":
+            if not self.config.get('release_source_url'):
+                self.logger.error("release_source 为 manifest 时必须设置 release_source_url")
+            return ManifestSource(self.config.get('release_source_url', ''), self.config.get_source_token())
+        return GitHubAPI(repo, GitHubAPI.enterprise_api_url(self.config.get('release_source_url', '')),
+                         token=self.config.get_source_token())
+
+    def get_current_version(self, zed_path: Optional[Path] = None) -> Optional[str]:
+        """Get currently installed Zed version, of zed_install_path by default"""
+        zed_path = str(zed_path) if zed_path else self.config.get('zed_install_path')
         if not zed_path or not Path(zed_path).exists():
             self.logger.warning(f"Zed executable not found: {zed_path}")
             return None
@@ -98,7 +311,7 @@ class ZedUpdater:
                     if match:
                         return match.group(1)
 
-            except (subprocess.TimeoutExpired, subprocess.SubprocessError):
+            except (subprocess.TimeoutExpired, subprocess.SubprocessError, OSError):
                 pass
 
         except Exception as e:
@@ -108,57 +321,83 @@ class ZedUpdater:
         return "unknown"
 
     def get_latest_version_info(self) -> Optional[ReleaseInfo]:
-        """Get latest version information from GitHub"""
-        try:
-            repo = self.config.get('github_repo', 'TC999/zed-loc')
-            url = f"https://api.github.com/repos/{repo}/releases/latest"
-            
-            response = self.session.get(url, timeout=30)
-            response.raise_for_status()
-            data = response.json()
-            
-            # Extract version from tag
-            tag_name = data.get('tag_name', '')
-            version = tag_name.lstrip('v') if tag_name else 'latest'
-            
-            # Find download URL (prefer Windows executables)
-            download_url = ""
-            for asset in data.get('assets', []):
-                name = asset.get('name', '').lower()
-                if 'windows' in name or 'win' in name or name.endswith('.exe'):
-                    download_url = asset.get('browser_download_url', '')
+        """Get latest version information from the release sources
+
+        With fallback_repos, the next source is asked when one is
+        unreachable or has nothing newer than the installed version. If
+        no source has an update, the first release found is returned.
+        ReleaseInfo.source names the source that supplied it.
+        """
+        current_version = self.get_current_version() if len(self.sources) > 1 else None
+        first_found = None
+
+        for source in self.sources:
+            try:
+                release_info = source.get_latest_release()
+            except RateLimitError as e:
+                self.logger.warning(f"{source.describe()} 请求受限，{e.retry_after:.0f} 秒后可重试: {e}")
+                continue
+            except Exception as e:
+                self.logger.error(f"Failed to get latest version info from {source.describe()}: {e}")
+                continue
+
+            if not release_info:
+                continue
+            if len(self.sources) == 1 or self._is_newer_version(current_version, release_info.version):
+                self.logger.info(f"Found latest version: {release_info.version} ({release_info.source})")
+                return release_info
+            self.logger.info(f"{source.describe()} 没有比 {current_version} 更新的版本")
+            first_found = first_found or release_info
+
+        return first_found
+
+    def get_release(self, tag: str) -> Optional[ReleaseInfo]:
+        """Look up a release by tag in the release sources, "v" prefix optional"""
+        tags = [tag] if tag.startswith('v') else [tag, f"v{tag}"]
+        for source in self.sources:
+            for candidate in tags:
+                try:
+                    release_info = source.get_release_by_tag(candidate)
+                except RateLimitError as e:
+                    self.logger.warning(f"{source.describe()} 请求受限，{e.retry_after:.0f} 秒后可重试: {e}")
                     break
-            
-            # Fallback to first asset
-            if not download_url and data.get('assets'):
-                download_url = data['assets'][0].get('browser_download_url', '')
-            
-            if not download_url:
-                self.logger.error("No suitable download asset found")
-                return None
-            
-            release_info = ReleaseInfo(
-                version=version,
-                release_date=datetime.fromisoformat(data['published_at'].replace('Z', '+00:00')),
-                download_url=download_url,
-                description=data.get('body', ''),
-                size=sum(asset.get('size', 0) for asset in data.get('assets', [])),
-                sha256=None
-            )
-            
-            self.logger.info(f"Found latest version: {version}")
-            return release_info
-            
-        except Exception as e:
-            self.logger.error(f"Failed to get latest version info: {e}")
-            return None
+                if release_info:
+                    return release_info
+        return None
+
+    def _source_for(self, release_info: ReleaseInfo) -> GitHubAPI:
+        """Release source a release was fetched from"""
+        return next((source for source in self.sources if source.describe() == release_info.source),
+                    self.source)
+
+    def get_rate_limit_status(self) -> List[Tuple[str, Dict[str, Any]]]:
+        """Current rate limit of every release source
+
+        Lets callers explain a skipped check instead of reporting a
+        generic failure. See GitHubAPI.fetch_rate_limit for the fields.
+        """
+        return [(source.describe(), source.fetch_rate_limit()) for source in self.sources]
+
+    def list_releases(self, page: int = 1, per_page: int = 10) -> List[ReleaseInfo]:
+        """One page of releases passing the release filter, newest first"""
+        try:
+            return self.source.get_releases(per_page, page)
+        except RateLimitError as e:
+            self.logger.warning(f"GitHub API 请求受限，{e.retry_after:.0f} 秒后可重试: {e}")
+            return []
 
     def check_for_updates(self) -> Optional[ReleaseInfo]:
-        """Check if updates are available"""
+        """Check if updates are available
+
+        None both without an update and when no source could be asked,
+        last_check_failed tells the two apart.
+        """
         current_version = self.get_current_version()
         latest_info = self.get_latest_version_info()
+        self.last_check_failed = latest_info is None
 
         if not latest_info:
+            self._record_metric(self.metrics.record_check, 'failed')
             return None
 
         self.logger.info(f"Current version: {current_version}")
@@ -167,148 +406,680 @@ class ZedUpdater:
         # For date-based versions or when forced, always consider as update available
         if (self.config.get('force_download_latest') or
             self._is_newer_version(current_version, latest_info.version)):
+            self._record_metric(self.metrics.record_check, 'update_available')
             return latest_info
 
+        self._record_metric(self.metrics.record_check, 'no_update')
         return None
 
+    def _no_update_result(self) -> UpdateResult:
+        """Result for a check that found nothing to install"""
+        if self.last_check_failed:
+            return UpdateResult(
+                success=False,
+                message="无法从任何发布源获取最新版本",
+                error_code="CHECK_FAILED"
+            )
+        return UpdateResult(
+            success=True,
+            message="没有可用的更新"
+        )
+
     def _is_newer_version(self, current: str, latest: str) -> bool:
-        """Compare version strings"""
+        """Compare version strings, see utils.version.compare_versions"""
         if not current or current == "unknown":
             return True
 
-        try:
-            # Handle date-based versions (e.g., "2024-01-15")
-            from datetime import datetime
-            try:
-                current_date = datetime.strptime(current, "%Y-%m-%d")
-                latest_date = datetime.strptime(latest, "%Y-%m-%d")
-                return latest_date > current_date
-            except ValueError:
-                pass
-
-            # Handle semantic versions
-            import re
-            current_parts = re.findall(r'\d+', current)
-            latest_parts = re.findall(r'\d+', latest)
+        result = compare_versions(latest, current)
+        if result is None:
+            self.logger.warning(f"无法比较版本 '{current}' 和 '{latest}'，视为有更新")
+            return True  # Assume newer if can't parse
+        return result > 0
 
-            if not current_parts or not latest_parts:
-                return True  # Assume newer if can't parse
+    def get_update_status(self, max_releases: int = 100) -> Optional[UpdateStatus]:
+        """Compare the installed version with the latest release
 
-            # Compare version parts
-            for c, l in zip(current_parts, latest_parts):
-                if int(l) > int(c):
-                    return True
-                elif int(l) < int(c):
-                    return False
+        The changelog joins the notes of every release newer than the
+        installed version up to the latest one, newest first. Only the
+        first max_releases releases are looked at.
 
-            # If all parts equal, check if latest has more parts
-            return len(latest_parts) > len(current_parts)
+        Returns:
+            None if the latest release could not be fetched
+        """
+        current_version = self.get_current_version()
+        latest_info = self.get_latest_version_info()
+        if not latest_info:
+            return None
 
-        except Exception as e:
-            self.logger.warning(f"Version comparison failed: {e}")
-            return True  # Assume update available on error
+        status = UpdateStatus(
+            current_version=current_version,
+            latest_version=latest_info.version,
+            update_available=self._is_newer_version(current_version, latest_info.version),
+            release=latest_info
+        )
+        if not status.update_available:
+            return status
+
+        releases = [release for release in self.list_releases(1, max_releases)
+                    if compare_versions(release.version, latest_info.version) in (-1, 0)
+                    and self._is_newer_version(current_version, release.version)
+                    and (latest_info.prerelease or not release.prerelease)]
+        if not any(release.version == latest_info.version for release in releases):
+            releases.insert(0, latest_info)
+
+        status.versions = [release.version for release in releases]
+        status.changelog = "\n\n".join(
+            f"## {release.version} ({release.release_date:%Y-%m-%d})\n\n{release.description.strip()}"
+            for release in releases
+        )
+        return status
+
+    def check_asset_size(self, size: int) -> Optional[str]:
+        """Check a download size against max_asset_size_mb
+
+        Returns:
+            Error message if the size is over the limit, None otherwise
+        """
+        limit_mb = self.config.get('max_asset_size_mb', 0)
+        if limit_mb and size > limit_mb * 1024 * 1024:
+            return f"更新文件大小 {format_size(size)} 超过限制 {limit_mb} MB"
+        return None
 
     def download_update(
         self,
         release_info: ReleaseInfo,
-        progress_callback: Optional[Callable[[float, str], None]] = None
+        progress_callback: Optional[Callable[[float, str], None]] = None,
+        ignore_size_limit: bool = False
     ) -> Optional[Path]:
-        """Download update file"""
+        """Download update file
+
+        Data is written to a ``.part`` file first so that a paused or
+        interrupted download can be resumed later with an HTTP Range request.
+
+        Args:
+            release_info: Release to download
+            progress_callback: Progress callback function
+            ignore_size_limit: Download even if the asset exceeds max_asset_size_mb
+        """
+        # A pause requested from here on belongs to this download
+        progress = DownloadProgress()
+        self._download_progress = progress
+        if not ignore_size_limit:
+            size_error = self.check_asset_size(release_info.size or 0)
+            if size_error:
+                self.logger.error(size_error)
+                return None
+
         try:
-            temp_dir = self.config.get_temp_dir()
-            temp_dir.mkdir(parents=True, exist_ok=True)
-            
-            download_path = temp_dir / f"zed_update_{release_info.version}.exe"
+            download_path = self.get_download_path(release_info)
+            download_path.parent.mkdir(parents=True, exist_ok=True)
+            part_path = download_path.with_name(download_path.name + '.part')
+            expected_size = release_info.size or 0
+
+            # Reuse a complete earlier download, resume a truncated one
+            if download_path.exists():
+                if self.verify_download(download_path, release_info):
+                    self.logger.info(f"使用已下载的文件: {download_path}")
+                    return download_path
+                self._recover_partial_download(download_path, part_path, expected_size)
             
-            self.logger.info(f"Downloading from: {release_info.download_url}")
+            direct_url, asset_headers = self._source_for(release_info).get_asset_download_request(release_info)
+            download_url = direct_url
+            expected_hash = release_info.sha256.lower() if release_info.sha256 else None
+            if self.accelerator.applies_to(direct_url, asset_headers):
+                # A mirror can hand out any file, so it is only used when the result can be checked
+                expected_hash = expected_hash or self._source_for(release_info).get_asset_checksum(
+                    release_info, self._asset_name(release_info))
+                if expected_hash:
+                    download_url = self.accelerator.apply(direct_url, asset_headers)
+                else:
+                    self.logger.warning("发布没有提供 SHA-256，无法校验加速镜像下载的文件，改为直接下载")
+            self.logger.info(f"Downloading from: {download_url}")
             
             timeout = self.config.get('download_timeout', 300)
             retry_count = self.config.get('retry_count', 3)
             
-            for attempt in range(retry_count):
+            tracker = TransferRateTracker()
+            started = time.monotonic()
+            transferred = 0
+
+            attempt = 0
+            while attempt < retry_count:
+                # Block here while the download is paused
+                while not progress.resumed.wait(self.EXIT_POLL_SECONDS) and not self._shutdown.is_set():
+                    pass
+                if self._shutdown.is_set():
+                    self.logger.info("正在退出，下载已中止，已下载的部分下次继续")
+                    return None
+
                 try:
-                    response = self.session.get(release_info.download_url, stream=True, timeout=timeout)
+                    resume_from = part_path.stat().st_size if part_path.exists() else 0
+                    headers = dict(asset_headers)
+                    if resume_from:
+                        headers['Range'] = f'bytes={resume_from}-'
+
+                    response = self.session.get(
+                        download_url, stream=True, timeout=timeout, headers=headers
+                    )
+
+                    if response.status_code == 416:
+                        response.close()
+                        if expected_size and part_path.stat().st_size == expected_size:
+                            # Partial file already holds the whole asset
+                            part_path.replace(download_path)
+                            self.logger.info(f"下载完成: {download_path}")
+                            return download_path
+                        # Stale partial file that no longer matches the asset
+                        part_path.unlink()
+                        continue
+
                     response.raise_for_status()
+
+                    if resume_from and response.status_code != 206:
+                        self.logger.info("服务器不支持断点续传，重新下载")
+                        resume_from = 0
+                    elif resume_from:
+                        self.logger.info(f"从 {resume_from} 字节处继续下载")
                     
-                    total_size = int(response.headers.get('content-length', 0))
-                    downloaded_size = 0
+                    content_length = int(response.headers.get('content-length', 0))
+                    total_size = content_length + resume_from if content_length else 0
+
+                    # The release metadata may not carry a size, check what the server reports
+                    size_error = None if ignore_size_limit else self.check_asset_size(total_size)
+                    if size_error:
+                        response.close()
+                        self.logger.error(size_error)
+                        return None
+                    downloaded_size = resume_from
+                    paused = False
+
+                    progress.downloaded = downloaded_size
+                    progress.total = total_size
+                    progress.paused = False
+                    tracker.reset()
+                    tracker.update(downloaded_size)
                     
-                    with open(download_path, 'wb') as f:
+                    with open(part_path, 'ab' if resume_from else 'wb') as f:
                         for chunk in response.iter_content(chunk_size=8192):
+                            if progress.pause_requested or self._shutdown.is_set():
+                                paused = True
+                                break
+
                             if chunk:
                                 f.write(chunk)
                                 downloaded_size += len(chunk)
+
+                                tracker.update(downloaded_size)
+                                progress.downloaded = downloaded_size
+                                progress.rate = tracker.rate
+                                progress.eta = tracker.eta(total_size)
                                 
                                 # Report progress
                                 if progress_callback and total_size > 0:
-                                    progress = (downloaded_size / total_size) * 100
-                                    progress_callback(progress, f"下载中... {progress:.1f}%")
+                                    progress_callback(
+                                        progress.percent,
+                                        f"下载中... {progress.percent:.1f}% "
+                                        f"({format_size(progress.rate)}/s, "
+                                        f"剩余 {format_duration(progress.eta)})"
+                                    )
+
+                    response.close()
+                    transferred += downloaded_size - resume_from
+
+                    if paused and self._shutdown.is_set():
+                        # Caught at the top of the loop
+                        continue
+                    if paused:
+                        progress.paused = True
+                        progress.rate = 0.0
+                        progress.eta = None
+                        self.logger.info(f"下载已暂停: {downloaded_size} 字节已保存")
+                        if progress_callback:
+                            progress_callback(progress.percent, f"已暂停 {progress.percent:.1f}%")
+                        continue
                     
+                    if total_size and downloaded_size < total_size:
+                        # Connection closed early; the part file is kept for resuming
+                        raise requests.exceptions.ConnectionError(
+                            f"下载被截断: 收到 {downloaded_size}/{total_size} 字节"
+                        )
+
+                    if ((total_size and downloaded_size > total_size) or
+                            (expected_size and downloaded_size != expected_size)):
+                        part_path.unlink()
+                        raise requests.exceptions.RequestException(
+                            f"文件大小不匹配: 收到 {downloaded_size} 字节, "
+                            f"预期 {expected_size or total_size} 字节"
+                        )
+
+                    # A broken mirror or captive portal answers with an HTML page;
+                    # retrying would only fetch the same page again
+                    asset_name = self._asset_name(release_info)
+                    valid, detected = validate_file_type(part_path, asset_name)
+                    if not valid and download_url != direct_url:
+                        part_path.unlink()
+                        self.logger.warning(f"加速镜像返回了无效内容 ({detected})，改为直接下载")
+                        download_url = direct_url
+                        continue
+                    if not valid:
+                        part_path.unlink()
+                        self.logger.error(
+                            f"下载的文件内容与类型不符 ({asset_name}: {detected})，"
+                            f"可能是代理或网络认证页面返回的错误内容"
+                        )
+                        return None
+
+                    if expected_hash and self._file_sha256(part_path) != expected_hash:
+                        part_path.unlink()
+                        if download_url != direct_url:
+                            self.logger.warning("加速镜像返回的文件 SHA-256 与发布不一致，改为直接下载")
+                            download_url = direct_url
+                            continue
+                        self.logger.error(f"下载的文件 SHA-256 与发布不一致: {asset_name}")
+                        return None
+                    
+                    part_path.replace(download_path)
                     self.logger.info(f"下载完成: {download_path}")
+                    self._record_metric(self.metrics.record_download, True, transferred,
+                                        time.monotonic() - started)
                     return download_path
                     
                 except requests.exceptions.RequestException as e:
-                    self.logger.warning(f"下载尝试 {attempt + 1} 失败: {e}")
-                    if attempt < retry_count - 1:
-                        time.sleep(2 ** attempt)
+                    attempt += 1
+                    self.logger.warning(f"下载尝试 {attempt} 失败: {e}")
+                    if download_url != direct_url:
+                        # The partial file holds the same bytes, resume it directly
+                        self.logger.warning("加速镜像下载失败，改为直接下载")
+                        download_url = direct_url
+                    if attempt < retry_count:
+                        self._shutdown.wait(2 ** (attempt - 1))
                         continue
                     else:
                         self.logger.error(f"下载失败，已重试 {retry_count} 次")
+                        self._record_metric(self.metrics.record_download, False, transferred)
                         return None
 
         except Exception as e:
             self.logger.error(f"下载错误: {e}")
             return None
 
-    def create_backup(self) -> Optional[Path]:
-        """Create backup of current Zed installation"""
-        if not self.config.get('backup_enabled'):
+    def download_delta_update(
+        self,
+        release_info: ReleaseInfo,
+        progress_callback: Optional[Callable[[float, str], None]] = None
+    ) -> Optional[Path]:
+        """Build the update from a binary patch against the installed executable
+
+        Only used when the release ships a patch for the installed version
+        together with the SHA-256 of the patched result, and bsdiff4 is
+        available. Returns None whenever the patch route does not work out,
+        so the caller falls back to a full download.
+        """
+        if not self.config.get('delta_updates', True):
+            return None
+
+        try:
+            import bsdiff4
+        except ImportError:
+            self.logger.debug("bsdiff4 未安装，跳过增量更新")
+            return None
+
+        download_dir = self.config.get_download_dir(release_info.version)
+        output_path = download_dir / f"zed_update_{release_info.version}.exe"
+        if output_path.exists():
+            # An earlier download is reused (or resumed) by download_update
             return None
 
         zed_path = Path(self.config.get('zed_install_path'))
+        current_version = self.get_current_version()
+        if not current_version or not zed_path.is_file():
+            return None
+
+        source = self._source_for(release_info)
+        patch_asset = source.find_delta_asset(release_info, current_version)
+        if not patch_asset:
+            return None
+
+        expected_hash = source.get_asset_checksum(release_info, patch_asset.name)
+        if not expected_hash:
+            self.logger.info(f"增量补丁 {patch_asset.name} 没有校验和，使用完整下载")
+            return None
+
+        download_dir.mkdir(parents=True, exist_ok=True)
+        patch_path = download_dir / patch_asset.name
+        staged_output = output_path.with_name(output_path.name + '.patched')
+
+        try:
+            if progress_callback:
+                progress_callback(0, f"正在下载增量补丁 ({format_size(patch_asset.size)})...")
+
+            url, headers = source.get_asset_request(patch_asset)
+            response = self.session.get(url, headers=headers,
+                                        timeout=self.config.get('download_timeout', 300))
+            response.raise_for_status()
+            if patch_asset.size and len(response.content) != patch_asset.size:
+                raise ValueError(f"补丁大小不匹配: {len(response.content)}/{patch_asset.size} 字节")
+            patch_path.write_bytes(response.content)
+
+            if progress_callback:
+                progress_callback(50, "正在应用增量补丁...")
+            bsdiff4.file_patch(str(zed_path), str(staged_output), str(patch_path))
+
+            actual_hash = self._file_sha256(staged_output)
+            if actual_hash != expected_hash:
+                raise ValueError(f"补丁结果校验失败: {actual_hash} != {expected_hash}")
+
+            staged_output.replace(output_path)
+            if progress_callback:
+                progress_callback(100, "增量补丁已应用")
+            self.logger.info(f"已通过增量补丁生成更新: {output_path}")
+            return output_path
+
+        except Exception as e:
+            self.logger.warning(f"增量更新失败，改用完整下载: {e}")
+            if staged_output.exists():
+                staged_output.unlink()
+            return None
+
+        finally:
+            if patch_path.exists():
+                patch_path.unlink()
+
+    def _download_release(
+        self,
+        release_info: ReleaseInfo,
+        progress_callback: Optional[Callable[[float, str], None]] = None
+    ) -> Optional[Path]:
+        """Get the update file, trying a delta patch before the full asset"""
+        return (self.download_delta_update(release_info, progress_callback) or
+                self.download_update(release_info, progress_callback))
+
+    def verify_download(self, path: Path, release_info: ReleaseInfo) -> bool:
+        """Check that a downloaded file is complete
+
+        Files are compared against the size the release declares for the
+        asset, and against its SHA-256 if the source publishes one. Without
+        either any non-empty file is accepted.
+        """
+        try:
+            size = Path(path).stat().st_size
+        except OSError:
+            return False
+
+        if release_info.size and size != release_info.size:
+            self.logger.warning(
+                f"下载文件不完整: {path} ({size}/{release_info.size} 字节)"
+            )
+            return False
+
+        if release_info.sha256 and self._file_sha256(Path(path)) != release_info.sha256.lower():
+            self.logger.warning(f"下载文件 SHA-256 与发布信息不一致: {path}")
+            return False
+
+        valid, detected = validate_file_type(path, self._asset_name(release_info))
+        if not valid:
+            self.logger.warning(f"下载文件类型无效: {path} ({detected})")
+            return False
+        return size > 0
+
+    def _asset_name(self, release_info: ReleaseInfo) -> str:
+        """Get the file name of the selected release asset"""
+        return Path(urlparse(release_info.download_url).path).name
+
+    def _recover_partial_download(self, download_path: Path, part_path: Path,
+                                  expected_size: int) -> None:
+        """Turn an invalid finished download into a resumable part file"""
+        try:
+            size = download_path.stat().st_size
+            part_size = part_path.stat().st_size if part_path.exists() else -1
+
+            if expected_size and 0 < size < expected_size and size > part_size:
+                # Truncated file, e.g. left by a crash before .part files existed
+                download_path.replace(part_path)
+                self.logger.info(f"将截断的下载文件转为续传文件: {part_path}")
+            else:
+                download_path.unlink()
+                self.logger.info(f"删除无效的下载文件: {download_path}")
+        except OSError as e:
+            self.logger.warning(f"处理不完整下载文件失败: {e}")
+
+    def get_download_progress(self) -> DownloadProgress:
+        """Get byte count, transfer rate and ETA of the current download"""
+        return self._download_progress
+
+    def pause_download(self) -> None:
+        """Pause the most recently started download, keeping the partial data"""
+        self._download_progress.pause()
+        self.logger.info("暂停下载")
+
+    def resume_download(self) -> None:
+        """Resume a paused download from where it stopped"""
+        self._download_progress.resume()
+        self.logger.info("继续下载")
+
+    def is_download_paused(self) -> bool:
+        """Check if the most recently started download is paused"""
+        return self._download_progress.pause_requested
+
+    def request_shutdown(self) -> None:
+        """Make running and future downloads and installs stop at the next safe point
+
+        Downloads stop and keep their partial file, the update pipeline
+        returns before it closes Zed. Installs already replacing files
+        finish, shutdown() waits for them.
+        """
+        if not self._shutdown.is_set():
+            self.logger.info("收到退出请求，正在停止后台任务")
+        self._shutdown.set()
+        # Paused downloads notice within EXIT_POLL_SECONDS, the latest one right away
+        self._download_progress.resume()
+
+    def is_shutting_down(self) -> bool:
+        return self._shutdown.is_set()
+
+    def is_busy(self) -> bool:
+        """Whether an install or rollback is replacing files right now"""
+        with self._busy:
+            return self._busy_count > 0
+
+    def shutdown(self, timeout: Optional[float] = None) -> bool:
+        """Request shutdown and wait for running installs and rollbacks
+
+        Returns:
+            False if one is still running after timeout. The journal lets
+            the next start finish or undo it.
+        """
+        self.request_shutdown()
+        with self._busy:
+            idle = self._busy.wait_for(lambda: self._busy_count == 0, timeout)
+        if not idle:
+            self.logger.warning("安装仍在进行，下次启动时将根据安装日志恢复")
+        return idle
+
+    def create_backup(self, zed_path: Optional[Path] = None) -> Optional[Path]:
+        """Create backup of current Zed installation, zed_install_path by default"""
+        if not self.config.get('backup_enabled'):
+            return None
+
+        zed_path = Path(zed_path or self.config.get('zed_install_path'))
         if not zed_path.exists():
             self.logger.warning("Zed executable not found, skipping backup")
             return None
 
+        # Zed's settings are per user, not per install path
+        parts = [part for part, key in (('config', 'backup_zed_config'),
+                                        ('extensions', 'backup_zed_extensions'))
+                 if self.config.get(key, False)]
+        if parts and self._backup_prefix(zed_path) != "zed_backup_":
+            parts = []
+
         try:
-            backup_dir = self.config.get_backup_dir()
+            # The settings may have changed even if the binary did not
+            if self.config.get('backup_dedup', True) and not parts:
+                duplicate = self._find_duplicate_backup(zed_path)
+                if duplicate:
+                    self.logger.info(f"当前程序与最新备份 {duplicate.name} 相同，跳过备份")
+                    self._record_dedup(duplicate)
+                    return duplicate
+
+            backup_dir = self.config.get_backup_dir(self.get_current_version(zed_path) or "unknown")
             backup_dir.mkdir(parents=True, exist_ok=True)
 
             # Clean old backups
-            self._cleanup_old_backups()
+            self._cleanup_old_backups(zed_path)
 
             # Create backup filename with timestamp
             timestamp = time.strftime("%Y%m%d_%H%M%S")
-            backup_path = backup_dir / f"zed_backup_{timestamp}.exe"
+            backup_path = backup_dir / f"{self._backup_prefix(zed_path)}{timestamp}.exe"
 
             # Copy file
             shutil.copy2(zed_path, backup_path)
+            self._write_backup_checksum(backup_path)
             self.logger.info(f"Backup created: {backup_path}")
+
+            if parts:
+                try:
+                    self.zed_config.create(self._config_backup_path(backup_path), parts)
+                except (OSError, ValueError) as e:
+                    self.logger.warning(f"备份 Zed 配置失败: {e}")
+
+            self.backup_mirror.mirror_async([
+                backup_path, self._checksum_path(backup_path), self._config_backup_path(backup_path)
+            ])
             return backup_path
 
         except Exception as e:
             self.logger.error(f"Failed to create backup: {e}")
             return None
 
-    def _cleanup_old_backups(self) -> None:
-        """Clean up old backup files"""
+    def _find_duplicate_backup(self, zed_path: Path) -> Optional[Path]:
+        """Newest backup of an install path if it is byte-identical to it"""
+        backups = self.list_backups(zed_path)
+        if not backups:
+            return None
+        newest = backups[0]
+        if newest.stat().st_size != zed_path.stat().st_size:
+            return None
+        recorded = self._read_backup_checksum(newest) or self._file_sha256(newest)
+        return newest if self._file_sha256(zed_path) == recorded else None
+
+    def _record_dedup(self, backup_path: Path) -> None:
+        """Count a skipped duplicate backup in the backup stats file"""
+        stats = self._load_dedup_stats()
+        stats['skipped'] = stats.get('skipped', 0) + 1
+        stats['bytes_saved'] = stats.get('bytes_saved', 0) + backup_path.stat().st_size
+        stats['last_skipped'] = datetime.now().isoformat(timespec='seconds')
+        stats['last_reused'] = backup_path.name
         try:
-            backup_dir = self.config.get_backup_dir()
-            if not backup_dir.exists():
-                return
+            stats_file = self.config.get_backup_stats_file()
+            stats_file.parent.mkdir(parents=True, exist_ok=True)
+            with open(stats_file, 'w', encoding='utf-8') as f:
+                json.dump(stats, f, indent=2, ensure_ascii=False)
+        except OSError as e:
+            self.logger.warning(f"保存备份统计失败: {e}")
+
+    def _load_dedup_stats(self) -> Dict[str, Any]:
+        try:
+            with open(self.config.get_backup_stats_file(), 'r', encoding='utf-8') as f:
+                stats = json.load(f)
+            return stats if isinstance(stats, dict) else {}
+        except (OSError, ValueError):
+            return {}
+
+    def get_backup_stats(self) -> Dict[str, Any]:
+        """Backup counts and sizes, including backups skipped as duplicates
+
+        Returns:
+            backups and total_bytes for the backups on disk, skipped and
+            bytes_saved for duplicates that were not written, and
+            last_skipped / last_reused for the most recent one.
+        """
+        backups = [backup for zed_path in self.config.get_install_paths()
+                   for backup in self.list_backups(zed_path)]
+        stats = {
+            'backups': len(backups),
+            'total_bytes': sum(backup.stat().st_size for backup in backups),
+            'skipped': 0,
+            'bytes_saved': 0,
+            'last_skipped': None,
+            'last_reused': None,
+        }
+        stats.update(self._load_dedup_stats())
+        return stats
+
+    def _config_backup_path(self, backup_path: Path) -> Path:
+        """Archive of Zed's settings saved along with a backup"""
+        return backup_path.with_name(backup_path.stem + '.config.zip')
+
+    def _checksum_path(self, backup_path: Path) -> Path:
+        """Sidecar file holding the SHA-256 recorded when a backup was made"""
+        return backup_path.with_name(backup_path.name + '.sha256')
+
+    def _write_backup_checksum(self, backup_path: Path) -> None:
+        """Record a backup's SHA-256 next to it, in sha256sum format"""
+        try:
+            self._checksum_path(backup_path).write_text(
+                f"{self._file_sha256(backup_path)}  {backup_path.name}\n", encoding='utf-8'
+            )
+        except OSError as e:
+            self.logger.warning(f"无法写入备份校验文件 {backup_path.name}: {e}")
 
+    def _read_backup_checksum(self, backup_path: Path) -> Optional[str]:
+        """SHA-256 recorded for a backup, None for backups made without one"""
+        try:
+            parts = self._checksum_path(backup_path).read_text(encoding='utf-8').split()
+        except OSError:
+            return None
+        return parts[0].lower() if parts and len(parts[0]) == 64 else None
+
+    def verify_backups(self, zed_path: Optional[Path] = None) -> List[Tuple[Path, str]]:
+        """Re-hash all backups of an install path against their recorded SHA-256
+
+        Returns:
+            (backup, status) pairs, newest first. status is "ok",
+            "corrupted" or "unverified" for backups without a checksum.
+        """
+        results = []
+        for backup_path in self.list_backups(zed_path):
+            recorded = self._read_backup_checksum(backup_path)
+            if not recorded:
+                status = "unverified"
+            else:
+                try:
+                    status = "ok" if self._file_sha256(backup_path) == recorded else "corrupted"
+                except OSError:
+                    status = "corrupted"
+            if status == "corrupted":
+                self.logger.warning(f"备份已损坏: {backup_path}")
+            results.append((backup_path, status))
+        return results
+
+    def _backup_prefix(self, zed_path: Optional[Path] = None) -> str:
+        """File name prefix of the backups of an install path
+
+        Backups of extra_install_paths carry a hash of their path so they
+        are never mistaken for (or rotated out with) the main install's.
+        """
+        primary = Path(self.config.get('zed_install_path'))
+        if zed_path is None or os.path.normcase(str(zed_path)) == os.path.normcase(str(primary)):
+            return "zed_backup_"
+        key = hashlib.sha1(os.path.normcase(str(zed_path)).encode('utf-8')).hexdigest()[:8]
+        return f"zed_backup-{key}_"
+
+    def _cleanup_old_backups(self, zed_path: Optional[Path] = None) -> None:
+        """Clean up old backup files"""
+        try:
             backup_count = self.config.get('backup_count', 3)
-            backup_files = list(backup_dir.glob("zed_backup_*.exe"))
+            backup_files = self.list_backups(zed_path)  # Newest first
 
             if len(backup_files) > backup_count:
-                # Sort by modification time (oldest first)
-                backup_files.sort(key=lambda x: x.stat().st_mtime)
-
                 # Remove oldest files
-                files_to_remove = backup_files[:-backup_count]
+                files_to_remove = backup_files[backup_count:]
                 for old_file in files_to_remove:
                     try:
                         old_file.unlink()
+                        self._checksum_path(old_file).unlink(missing_ok=True)
+                        self._config_backup_path(old_file).unlink(missing_ok=True)
                         self.logger.debug(f"Removed old backup: {old_file}")
                     except Exception as e:
                         self.logger.warning(f"Failed to remove old backup {old_file}: {e}")
@@ -316,106 +1087,1364 @@ class ZedUpdater:
         except Exception as e:
             self.logger.warning(f"Failed to cleanup backups: {e}")
 
-    def install_update(self, download_path: Path) -> UpdateResult:
-        """Install downloaded update"""
-        zed_path = Path(self.config.get('zed_install_path'))
+    def get_download_path(self, release_info: ReleaseInfo) -> Path:
+        """Get the path a release is downloaded to
+
+        Installer assets keep a "-setup" marker (and MSIs their suffix) so
+        install_update can still tell them apart from a portable binary.
+        """
+        asset_name = self._asset_name(release_info)
+        suffix = archive_suffix(asset_name) or ('.msi' if asset_name.lower().endswith('.msi') else '.exe')
+        marker = "-setup" if self.is_installer_asset(asset_name) else ""
+        return (self.config.get_download_dir(release_info.version) /
+                f"zed_update_{release_info.version}{marker}{suffix}")
+
+    def is_installer_asset(self, filename: str) -> bool:
+        """Check if an asset is an MSI or setup program rather than Zed itself"""
+        name = filename.lower()
+        return name.endswith('.msi') or (
+            name.endswith('.exe') and any(word in name for word in self.INSTALLER_MARKERS)
+        )
+
+    def _uses_installer(self, download_path: Path) -> bool:
+        """Decide by install_mode whether a download is run as an installer"""
+        mode = self.config.get('install_mode', 'auto')
+        if mode == 'installer':
+            return True
+        if mode == 'portable':
+            return False
+        return self.is_installer_asset(download_path.name)
 
-        try:
-            # Stop Zed processes
-            self._stop_zed_processes()
+    def _installer_command(self, download_path: Path) -> List[str]:
+        """Command line running an installer silently
 
-            # Create backup first
-            backup_path = self.create_backup()
-            if backup_path:
-                self.logger.info(f"Backup created before installation: {backup_path}")
+        installer_args replaces the default silent flags: /quiet for MSI,
+        /S for other setup programs (NSIS; Inno Setup wants /VERYSILENT).
+        """
+        configured = list(self.config.get('installer_args', []) or [])
+        if download_path.suffix.lower() == '.msi':
+            return ['msiexec', '/i', str(download_path)] + (configured or ['/quiet', '/norestart'])
+        return [str(download_path)] + (configured or ['/S'])
 
-            # Install new version
-            self.logger.info(f"Installing update from {download_path} to {zed_path}")
+    def install_update(
+        self,
+        download_path: Path,
+        progress_callback: Optional[Callable[[float, str], None]] = None,
+        conflict_resolver: Optional[Callable[[LockConflict], str]] = None,
+        skip_backup: bool = False,
+        targets: Optional[List[Path]] = None,
+        keep_download: bool = False,
+        dry_run: bool = False,
+        expected_version: Optional[str] = None,
+        defer_until_exit: bool = False,
+        source: Optional[str] = None,
+        stop_result: Optional[ZedStopResult] = None
+    ) -> UpdateResult:
+        """Install downloaded update
+
+        The new file is first copied next to the target (same directory, so
+        same filesystem) and then renamed over it with os.replace, so the
+        install path always holds either the complete old or the complete
+        new executable.
+
+        Args:
+            download_path: Downloaded update file
+            progress_callback: Progress callback function
+            conflict_resolver: Asked what to do when the target stays locked,
+                returns one of LockConflict.options. Without it the running
+                file is moved aside or the install is scheduled on exit.
+            skip_backup: Don't back up the current version first
+            targets: Install paths to update, zed_install_path and
+                extra_install_paths by default
+            keep_download: Leave the downloaded file in place afterwards
+            dry_run: Only run the checks and describe what would happen in
+                the result's actions, see _plan_target
+            expected_version: Version the installer must leave behind,
+                checked in installer mode
+            defer_until_exit: Don't close a running Zed. The update is
+                staged and applied as soon as Zed exits (install_method
+                "scheduled"), or on the next start if this process ends first.
+            source: Release source the download came from, for the history
+            stop_result: How the caller already stopped Zed. Zed is otherwise
+                stopped once here, before the first target is installed.
+
+        Returns:
+            The result of the only target, or a summary whose target_results
+            holds one result per target
+        """
+        download_path = Path(download_path)
+        targets = [Path(target) for target in targets] if targets else self.config.get_install_paths()
+        extract_dir = None
+        results: List[UpdateResult] = []
+
+        if self._uses_installer(download_path):
+            # The installer decides where Zed goes, so there is one target
+            zed_path = targets[0]
+            if dry_run:
+                result = UpdateResult(
+                    success=True,
+                    message="Dry run: the installer would be run",
+                    install_method="dry_run",
+                    actions=[f"运行安装程序: {' '.join(self._installer_command(download_path))}",
+                             f"安装后检查 {zed_path} 的版本"]
+                )
+            elif not can_write_to(zed_path.parent) and not is_admin():
+                result = self._install_elevated(download_path, progress_callback, skip_backup, zed_path)
+            elif defer_until_exit and self._find_zed_processes():
+                # Only this process knows about it, nothing is staged on disk
+                self._after_zed_exit(lambda: self._run_installer(
+                    download_path, zed_path, None, skip_backup, expected_version, source
+                ))
+                if progress_callback:
+                    progress_callback(100, "安装程序将在 Zed 退出后运行")
+                return UpdateResult(
+                    success=True,
+                    message="The installer will run when Zed exits",
+                    install_method="scheduled",
+                    install_path=str(zed_path)
+                )
+            else:
+                result = self._run_installer(download_path, zed_path, progress_callback,
+                                             skip_backup, expected_version, source, stop_result)
+            if result.success and not keep_download and not dry_run:
+                try:
+                    download_path.unlink()
+                except OSError as e:
+                    self.logger.debug(f"Failed to remove downloaded file {download_path}: {e}")
+            result.install_path = str(zed_path)
+            return result
+
+        def report(index: int):
+            def callback(progress: float, message: str) -> None:
+                if progress_callback:
+                    if len(targets) > 1:
+                        progress = (index * 100 + progress) / len(targets)
+                        message = f"({index + 1}/{len(targets)}) {message}"
+                    progress_callback(progress, message)
+            return callback
+
+        source_path = download_path
+        if archive_suffix(download_path.name):
+            report(0)(0, "正在解压更新包...")
+            try:
+                if dry_run:
+                    # Nothing may be written next to the download either
+                    extract_dir = Path(tempfile.mkdtemp(prefix='zed_dry_run_', dir=self._temp_dir())) / 'extracted'
+                else:
+                    extract_dir = download_path.with_name(download_path.name + '.extracted')
+                source_path = self._extract_binary(download_path, extract_dir, targets[0])
+            except (InstallationError, OSError) as e:
+                if extract_dir:
+                    shutil.rmtree(extract_dir.parent if dry_run else extract_dir, ignore_errors=True)
+                self.logger.error(f"Installation failed: {e}")
+                result = UpdateResult(
+                    success=False,
+                    message=f"Installation failed: {e}",
+                    error_code="INSTALL_FAILED"
+                )
+                if not dry_run:
+                    self._record_history("install", result, self.get_current_version(targets[0]), None,
+                                         source)
+                return result
+
+        if not dry_run and stop_result is None and not (defer_until_exit and self._find_zed_processes()):
+            # Once for all targets, _install_target doesn't stop Zed itself
+            report(0)(10, "正在停止 Zed...")
+            stop_result = self.stop_zed()
+
+        for index, zed_path in enumerate(targets):
+            if dry_run:
+                result = self._plan_target(source_path, zed_path, skip_backup)
+            elif not can_write_to(zed_path.parent) and not is_admin():
+                # The elevated copy gets the original download and unpacks it itself
+                result = self._install_elevated(download_path, report(index), skip_backup, zed_path)
+            else:
+                result = self._install_target(
+                    source_path, zed_path, report(index), conflict_resolver, skip_backup,
+                    defer_until_exit, source, stop_result
+                )
+            result.install_path = str(zed_path)
+            results.append(result)
 
-            # For safety, create a temporary backup of current file
-            temp_backup = zed_path.with_suffix('.tmp')
-            if zed_path.exists():
-                shutil.move(zed_path, temp_backup)
+        if extract_dir:
+            shutil.rmtree(extract_dir.parent if dry_run else extract_dir, ignore_errors=True)
 
+        if not keep_download and not dry_run and all(result.success for result in results):
             try:
-                # Move new file to installation location
-                shutil.move(download_path, zed_path)
+                download_path.unlink()
+            except OSError as e:
+                self.logger.debug(f"Failed to remove downloaded file {download_path}: {e}")
+
+        if len(results) == 1:
+            return results[0]
+
+        failed = [result for result in results if not result.success]
+        return UpdateResult(
+            success=not failed,
+            message=(("Dry run: all install checks passed" if dry_run else
+                      f"Update installed to {len(results)} targets") if not failed else
+                     f"Installation failed for {len(failed)} of {len(results)} targets: " +
+                     "; ".join(f"{result.install_path}: {result.message}" for result in failed)),
+            version=results[0].version,
+            error_code=failed[0].error_code if failed else None,
+            install_method=results[0].install_method,
+            install_path=results[0].install_path,
+            target_results=results,
+            actions=[action for result in results for action in result.actions]
+        )
+
+    @critical_section
+    def _run_installer(
+        self,
+        download_path: Path,
+        zed_path: Path,
+        progress_callback: Optional[Callable[[float, str], None]] = None,
+        skip_backup: bool = False,
+        expected_version: Optional[str] = None,
+        source: Optional[str] = None,
+        stop_result: Optional[ZedStopResult] = None
+    ) -> UpdateResult:
+        """Install by running a downloaded MSI or setup program
+
+        Zed is stopped first unless stop_result says the caller already did.
+        """
+        previous_version = self.get_current_version(zed_path)
+        backup_path = None
+
+        def report(progress: float, message: str) -> None:
+            if progress_callback:
+                progress_callback(progress, message)
+
+        try:
+            if stop_result is None:
+                report(10, "正在停止 Zed...")
+                self.stop_zed()
+
+            if not skip_backup:
+                report(20, "正在备份当前版本...")
+                backup_path = self.create_backup(zed_path)
+
+            command = self._installer_command(download_path)
+            timeout = self.config.get('installer_timeout', 600)
+            report(30, "正在运行安装程序...")
+            self.logger.info(f"Running installer: {' '.join(command)}")
+            try:
+                completed = subprocess.run(command, capture_output=True, timeout=timeout)
+            except subprocess.TimeoutExpired:
+                raise InstallationError(f"安装程序在 {timeout} 秒内未结束")
+
+            if completed.returncode == self.MSI_REBOOT_REQUIRED:
+                self.logger.warning("安装程序要求重启系统以完成安装")
+            elif completed.returncode != 0:
+                hint = self.INSTALLER_EXIT_CODES.get(completed.returncode, "")
+                raise InstallationError(
+                    f"安装程序退出代码 {completed.returncode}" + (f" ({hint})" if hint else "")
+                )
+
+            report(90, "正在验证安装结果...")
+            installed_version = self.get_current_version(zed_path)
+            if not zed_path.exists():
+                raise InstallationError(f"安装程序运行完成，但未找到 {zed_path}")
+            if expected_version and not self._version_matches(installed_version, expected_version):
+                raise InstallationError(
+                    f"安装后的版本 {installed_version} 与预期版本 {expected_version} 不符"
+                )
+
+            report(100, "安装完成")
+            result = UpdateResult(
+                success=True,
+                message="Update installed successfully (installer)",
+                version=installed_version,
+                install_method="installer"
+            )
+
+        except Exception as e:
+            error_msg = f"Installation failed: {e}"
+            self.logger.error(error_msg)
+            result = UpdateResult(
+                success=False,
+                message=error_msg,
+                error_code="INSTALL_FAILED"
+            )
+
+        self._record_history("install", result, previous_version, backup_path, source)
+        return result
+
+    def _version_matches(self, installed: Optional[str], expected: str) -> bool:
+        """Compare the x.y.z parts of two versions, see _run_installer
+
+        File versions carry a fourth component and tags may carry
+        suffixes, so only the first three numbers are compared. Versions
+        without them can't be checked and always match.
+        """
+        pattern = re.compile(r'\d+\.\d+\.\d+')
+        expected_match = pattern.search(expected or "")
+        if not expected_match:
+            return True
+        installed_match = pattern.search(installed or "")
+        return bool(installed_match) and installed_match.group(0) == expected_match.group(0)
+
+    def _plan_target(self, source_path: Path, zed_path: Path, skip_backup: bool = False) -> UpdateResult:
+        """Run the checks of _install_target without changing anything
+
+        The result lists the steps an install would take in actions and
+        fails with DRY_RUN_FAILED if any of them would not work.
+        """
+        actions: List[str] = []
+        problems: List[str] = []
 
-                # Set executable permissions (in case)
-                os.chmod(zed_path, 0o755)
+        try:
+            self._verify_install_file(source_path)
+            actions.append(f"校验通过: {source_path.name} ({detect_file_type(source_path)}, "
+                           f"SHA-256 {self._file_sha256(source_path)})")
+        except (InstallationError, OSError) as e:
+            problems.append(str(e))
+
+        if not can_write_to(zed_path.parent):
+            if is_admin():
+                problems.append(f"安装目录不可写: {zed_path.parent}")
+            else:
+                actions.append(f"请求管理员权限以写入 {zed_path.parent}")
+
+        required = source_path.stat().st_size if source_path.exists() else 0
+        free = self._free_space(zed_path.parent)
+        if free is not None and free < required * 2:  # Staged copy next to the old one
+            problems.append(f"磁盘空间不足: {zed_path.parent} 需要 {format_size(required * 2)}，"
+                            f"可用 {format_size(free)}")
+
+        if zed_path.exists():
+            if skip_backup or not self.config.get('backup_enabled'):
+                actions.append("不备份当前版本")
+            else:
+                backup_dir = self.config.get_backup_dir(self.get_current_version(zed_path) or "unknown")
+                backup_free = self._free_space(backup_dir)
+                if not can_write_to(backup_dir):
+                    problems.append(f"备份目录不可写: {backup_dir}")
+                elif backup_free is not None and backup_free < zed_path.stat().st_size:
+                    problems.append(f"备份目录空间不足: {backup_dir}")
+                else:
+                    actions.append(f"备份当前版本到 {backup_dir}")
+
+            holders = self.describe_lock_conflict(zed_path).processes
+            if holders:
+                pids = ', '.join(str(info['pid']) for info in holders)
+                actions.append(f"关闭正在运行的 Zed (PID {pids})，"
+                               f"{self.config.get('zed_close_timeout', 10)} 秒后强制结束")
+            actions.append(f"替换 {zed_path}")
+        else:
+            actions.append(f"新安装到 {zed_path}")
+
+        for problem in problems:
+            self.logger.warning(f"试运行发现问题 ({zed_path}): {problem}")
+        return UpdateResult(
+            success=not problems,
+            message="; ".join(problems) if problems else "Dry run: all install checks passed",
+            error_code="DRY_RUN_FAILED" if problems else None,
+            install_method="dry_run",
+            actions=actions
+        )
+
+    def _free_space(self, directory: Path) -> Optional[int]:
+        """Free bytes on the filesystem of directory (or its nearest existing parent)"""
+        directory = Path(directory)
+        while not directory.exists() and directory.parent != directory:
+            directory = directory.parent
+        try:
+            return shutil.disk_usage(directory).free
+        except OSError:
+            return None
+
+    @critical_section
+    def _install_target(
+        self,
+        source_path: Path,
+        zed_path: Path,
+        progress_callback: Optional[Callable[[float, str], None]] = None,
+        conflict_resolver: Optional[Callable[[LockConflict], str]] = None,
+        skip_backup: bool = False,
+        defer_until_exit: bool = False,
+        source: Optional[str] = None,
+        stop_result: Optional[ZedStopResult] = None
+    ) -> UpdateResult:
+        """Install an unpacked executable to one install path
+
+        Zed has been stopped by the caller (stop_result), unless
+        defer_until_exit waits for it to exit.
+        """
+        staged_path = zed_path.with_name(f".{zed_path.name}.new")
+        previous_version = self.get_current_version(zed_path)
+        backup_path = None
+        journal_entry = None
+
+        def report(progress: float, message: str) -> None:
+            if progress_callback:
+                progress_callback(progress, message)
+
+        try:
+            report(5, "正在验证更新文件...")
+            self._verify_install_file(source_path)
+            journal_entry = self.journal.begin(zed_path, staged_path)
+
+            defer = defer_until_exit and bool(self._find_zed_processes())
+            if not defer and stop_result and stop_result.running:
+                # Handled as a locked file below
+                self.logger.warning(f"Zed 仍在运行 (PID {', '.join(map(str, stop_result.running))})，"
+                                    f"文件可能被占用")
+
+            # Create backup first
+            if not skip_backup:
+                report(20, "正在备份当前版本...")
+                backup_path = self.create_backup(zed_path)
+                if backup_path:
+                    self.logger.info(f"Backup created before installation: {backup_path}")
+                    self.journal.update(journal_entry, step='backup_created', backup=str(backup_path))
 
-                # Remove temporary backup
-                if temp_backup.exists():
-                    temp_backup.unlink()
+            # Install new version
+            self.logger.info(f"Installing update from {source_path} to {zed_path}")
+            zed_path.parent.mkdir(parents=True, exist_ok=True)
 
+            try:
+                report(40, "正在写入新版本...")
+                shutil.copyfile(source_path, staged_path)
+                with open(staged_path, 'rb+') as f:
+                    os.fsync(f.fileno())
+
+                # Keep the permissions the binary shipped with, but make sure it can run
+                os.chmod(staged_path, stat.S_IMODE(source_path.stat().st_mode) | 0o755)
+                self.journal.update(journal_entry, step='staged',
+                                    staged_sha256=self._file_sha256(staged_path))
+
+                if defer:
+                    self._schedule_replace_on_exit(staged_path, zed_path)
+                    install_method = "scheduled"
+                else:
+                    report(80, "正在替换可执行文件...")
+                    install_method = self._replace_executable(
+                        staged_path, zed_path, conflict_resolver, journal_entry
+                    )
+
+            except Exception:
+                if staged_path.exists():
+                    try:
+                        staged_path.unlink()
+                    except OSError as cleanup_error:
+                        self.logger.warning(f"Failed to remove staged file {staged_path}: {cleanup_error}")
+                raise
+
+            if install_method == "scheduled":
+                report(100, "Zed 正在运行，更新将在其退出后应用")
+                result = UpdateResult(
+                    success=True,
+                    message="Zed is still running, the update will be applied when it exits",
+                    install_method=install_method
+                )
+            else:
+                report(100, "安装完成")
                 self.logger.info("Update installation completed successfully")
-                return UpdateResult(
+                result = UpdateResult(
                     success=True,
                     message="Update installed successfully",
-                    version=self._extract_version_from_file(zed_path)
+                    version=self.get_current_version(zed_path),
+                    install_method=install_method
                 )
 
-            except Exception as install_error:
-                # Restore from temporary backup
-                if temp_backup.exists():
+        except Exception as e:
+            error_msg = f"Installation failed: {e}"
+            self.logger.error(error_msg)
+            result = UpdateResult(
+                success=False,
+                message=error_msg,
+                error_code="INSTALL_FAILED"
+            )
+
+        # A scheduled install is open until _apply_staged has replaced the file
+        if journal_entry and result.install_method != "scheduled":
+            self.journal.finish(journal_entry)
+        self._record_history("install", result, previous_version, backup_path, source)
+        return result
+
+    @critical_section
+    def _install_elevated(
+        self,
+        download_path: Path,
+        progress_callback: Optional[Callable[[float, str], None]] = None,
+        skip_backup: bool = False,
+        zed_path: Optional[Path] = None
+    ) -> UpdateResult:
+        """Run the install in an elevated copy of the CLI
+
+        Used when the install directory (e.g. under Program Files) is not
+        writable. The elevated process records the install history itself
+        and leaves the download for this process to clean up.
+        """
+        if zed_path is None:
+            zed_path = Path(self.config.get('zed_install_path'))
+        if progress_callback:
+            progress_callback(0, "安装目录需要管理员权限，正在请求提升...")
+
+        args = ['--install-file', str(download_path.resolve()),
+                '--install-target', str(zed_path),
+                '--config', str(self.config.config_file.resolve()), '--quiet']
+        if skip_backup:
+            args.append('--no-backup')
+        try:
+            exit_code = ElevationHelper().run_elevated(args)
+        except ElevationError as e:
+            self.logger.error(f"Installation failed: {e}")
+            return UpdateResult(
+                success=False,
+                message=str(e),
+                error_code="ELEVATION_DECLINED" if e.declined else "ELEVATION_FAILED"
+            )
+
+        if exit_code != 0:
+            return UpdateResult(
+                success=False,
+                message=f"Elevated installation failed with exit code {exit_code}, see the log for details",
+                error_code="INSTALL_FAILED"
+            )
+
+        if progress_callback:
+            progress_callback(100, "安装完成")
+        return UpdateResult(
+            success=True,
+            message="Update installed successfully (elevated)",
+            version=self.get_current_version(zed_path),
+            install_method="elevated"
+        )
+
+    def _extract_binary(self, archive_path: Path, extract_dir: Path, zed_path: Path) -> Path:
+        """Unpack an archive asset and locate the Zed binary inside it"""
+        if extract_dir.exists():
+            shutil.rmtree(extract_dir)
+
+        try:
+            extract_archive(archive_path, extract_dir)
+        except (ValueError, OSError) as e:
+            raise InstallationError(f"解压更新包失败: {e}")
+
+        inner_path = self.config.get('archive_binary_path', '')
+        binary = find_binary(extract_dir, inner_path, names=(zed_path.name, 'zed.exe', 'zed'))
+        if not binary:
+            raise InstallationError(
+                f"更新包中未找到 Zed 可执行文件: {inner_path or zed_path.name}"
+            )
+
+        self.logger.info(f"从更新包中找到可执行文件: {binary.relative_to(extract_dir)}")
+        return binary
+
+    def list_backups(self, zed_path: Optional[Path] = None) -> list:
+        """List backup files of an install path (zed_install_path by default), newest first
+
+        Backups still in the pre-migration location next to the install
+        are included so they stay usable for rollback.
+        """
+        pattern = f"{self._backup_prefix(zed_path)}*.exe"
+        backups = []
+        # Templated backup_dir settings spread backups over one directory per version
+        for backup_dir in self.config.get_backup_dirs() + [self.config.get_legacy_locations()['backups']]:
+            if backup_dir.is_dir():
+                backups.extend(path for path in backup_dir.glob(pattern) if path not in backups)
+        backups.sort(key=lambda x: (x.stat().st_mtime, x.name), reverse=True)
+        return backups
+
+    def find_backup(self, name: str) -> Optional[Path]:
+        """Look up a backup of any install path by file name or path"""
+        wanted = Path(name)
+        for zed_path in self.config.get_install_paths():
+            for backup in self.list_backups(zed_path):
+                if backup.name == wanted.name and (not wanted.is_absolute() or backup == wanted):
+                    return backup
+        return None
+
+    def delete_backup(self, name: str) -> UpdateResult:
+        """Delete one backup together with its checksum and settings archive
+
+        Only files list_backups knows about can be deleted. A backup that
+        is being restored, or that an interrupted install may still need
+        for recovery, is refused.
+        """
+        backup_path = self.find_backup(name)
+        if backup_path is None:
+            return UpdateResult(success=False, message=f"备份不存在: {name}", error_code="BACKUP_NOT_FOUND")
+
+        with self._restoring_lock:
+            in_use = backup_path in self._restoring
+        pending = {Path(entry.backup) for entry in self.journal.pending() if entry.backup}
+        if in_use or backup_path in pending:
+            return UpdateResult(
+                success=False,
+                message=f"备份 {backup_path.name} 正在用于恢复，无法删除",
+                error_code="BACKUP_IN_USE"
+            )
+
+        try:
+            backup_path.unlink()
+            self._checksum_path(backup_path).unlink(missing_ok=True)
+            self._config_backup_path(backup_path).unlink(missing_ok=True)
+        except OSError as e:
+            self.logger.error(f"删除备份失败 {backup_path}: {e}")
+            self._audit("delete_backup", False, backup=str(backup_path), message=str(e))
+            return UpdateResult(success=False, message=f"删除备份失败: {e}", error_code="DELETE_FAILED")
+
+        self.logger.info(f"已删除备份: {backup_path}")
+        self._audit("delete_backup", True, backup=str(backup_path))
+        return UpdateResult(success=True, message=f"Deleted backup {backup_path.name}")
+
+    @critical_section
+    def rollback(
+        self,
+        backup_path: Optional[Path] = None,
+        progress_callback: Optional[Callable[[float, str], None]] = None,
+        restore_binary: bool = True,
+        restore_config: bool = False
+    ) -> UpdateResult:
+        """Restore a backup over the install path
+
+        Args:
+            backup_path: Backup to restore, defaults to the newest one
+            progress_callback: Progress callback function
+            restore_binary: Restore the Zed executable
+            restore_config: Restore Zed's settings and extensions saved
+                with the backup (see backup_zed_config)
+
+        The current executable is not backed up first, so rolling back a
+        broken build does not push a good backup out of the rotation.
+
+        Raises:
+            InstallationError: If neither the binary nor the config is to be restored
+        """
+        if not restore_binary and not restore_config:
+            raise InstallationError("回滚至少需要恢复可执行文件或 Zed 配置之一")
+
+        zed_path = Path(self.config.get('zed_install_path'))
+        staged_path = zed_path.with_name(f".{zed_path.name}.new")
+        previous_version = self.get_current_version()
+
+        def report(progress: float, message: str) -> None:
+            if progress_callback:
+                progress_callback(progress, message)
+
+        try:
+            if backup_path is None:
+                backups = self.list_backups()
+                if not backups:
+                    raise InstallationError("没有可用的备份")
+                backup_path = backups[0]
+            backup_path = Path(backup_path)
+            with self._restoring_lock:
+                self._restoring.add(backup_path)
+
+            config_archive = self._config_backup_path(backup_path)
+            if restore_config and not config_archive.exists():
+                raise InstallationError(f"备份 {backup_path.name} 不包含 Zed 配置")
+
+            report(0, f"正在验证备份 {backup_path.name}...")
+            self._verify_install_file(backup_path)
+            expected_hash = self._file_sha256(backup_path)
+            recorded_hash = self._read_backup_checksum(backup_path)
+            if recorded_hash and recorded_hash != expected_hash:
+                raise InstallationError(f"备份已损坏 (SHA-256 与备份时记录的不一致): {backup_path.name}")
+
+            report(20, "正在停止 Zed...")
+            self.stop_zed()
+
+            if restore_config:
+                report(30, "正在恢复 Zed 配置...")
+                self.zed_config.restore(config_archive, ZedConfigBackup.PARTS, self._temp_dir())
+                if not restore_binary:
+                    report(100, "配置恢复完成")
+                    result = UpdateResult(
+                        success=True,
+                        message=f"Restored Zed settings from {config_archive.name}",
+                        version=previous_version
+                    )
+                    self._record_history("rollback", result, previous_version, backup_path)
+                    return result
+
+            self.logger.info(f"Rolling back {zed_path} to {backup_path}")
+            try:
+                report(40, "正在恢复备份...")
+                shutil.copyfile(backup_path, staged_path)
+                with open(staged_path, 'rb+') as f:
+                    os.fsync(f.fileno())
+                os.chmod(staged_path, 0o755)
+
+                report(80, "正在替换可执行文件...")
+                install_method = self._replace_executable(staged_path, zed_path)
+            except Exception:
+                if staged_path.exists():
                     try:
-                        shutil.move(temp_backup, zed_path)
-                        self.logger.info("Restored from backup after installation failure")
-                    except Exception as restore_error:
-                        self.logger.error(f"Failed to restore backup: {restore_error}")
+                        staged_path.unlink()
+                    except OSError as cleanup_error:
+                        self.logger.warning(f"Failed to remove staged file {staged_path}: {cleanup_error}")
+                raise
+
+            if install_method == "scheduled":
+                report(100, "Zed 正在运行，回滚将在其退出后应用")
+                result = UpdateResult(
+                    success=True,
+                    message="Zed is still running, the rollback will be applied when it exits",
+                    install_method=install_method
+                )
+            else:
+                if self._file_sha256(zed_path) != expected_hash:
+                    raise InstallationError("回滚后的文件与备份不一致")
 
-                raise install_error
+                report(100, "回滚完成")
+                self.logger.info(f"Rollback to {backup_path.name} completed successfully")
+                result = UpdateResult(
+                    success=True,
+                    message=f"Restored backup {backup_path.name}" + (" with Zed settings" if restore_config else ""),
+                    version=self.get_current_version(),
+                    install_method=install_method
+                )
 
         except Exception as e:
-            error_msg = f"Installation failed: {e}"
+            error_msg = f"Rollback failed: {e}"
             self.logger.error(error_msg)
-            return UpdateResult(
+            result = UpdateResult(
                 success=False,
                 message=error_msg,
-                error_code="INSTALL_FAILED"
+                error_code="ROLLBACK_FAILED"
             )
+        finally:
+            if backup_path is not None:
+                with self._restoring_lock:
+                    self._restoring.discard(Path(backup_path))
+
+        self._record_history("rollback", result, previous_version, backup_path)
+        return result
+
+    def collect_crash_logs(self) -> List[Path]:
+        """Attach Zed crash logs written since the last install to its history entry
 
-    def _stop_zed_processes(self) -> None:
-        """停止所有Zed进程"""
+        The logs are copied into the updater's data directory, Zed rotates
+        its own logs and would otherwise lose the evidence.
+
+        Returns:
+            Newly collected copies
+        """
         try:
-            zed_processes = []
-            for proc in psutil.process_iter(['pid', 'name', 'exe']):
+            entry = next((e for e in self.history.get_entries() if e.success), None)
+            if not entry:
+                return []
+
+            known = {Path(path).name for path in entry.crash_logs}
+            since = datetime.fromisoformat(entry.timestamp)
+            new_logs = [path for path in CrashLogCollector().find_crash_logs(since)
+                        if path.name not in known]
+            if not new_logs:
+                return []
+
+            dest_dir = self.config.get_crash_log_dir() / f"{entry.action}_{entry.timestamp.replace(':', '')}"
+            dest_dir.mkdir(parents=True, exist_ok=True)
+
+            collected = []
+            for log_path in new_logs:
+                target = dest_dir / log_path.name
                 try:
-                    if (proc.info['name'] and 'zed' in proc.info['name'].lower() and
-                        proc.info['exe'] and Path(proc.info['exe']).name.lower().startswith('zed')):
-                        zed_processes.append(proc)
-                except (psutil.NoSuchProcess, psutil.AccessDenied):
-                    continue
+                    shutil.copy2(log_path, target)
+                    collected.append(target)
+                except OSError as e:
+                    self.logger.warning(f"复制崩溃日志失败 {log_path}: {e}")
+
+            if collected:
+                self.history.attach_crash_logs(entry.timestamp, entry.action,
+                                               [str(path) for path in collected])
+                self.logger.warning(
+                    f"版本 {entry.version or '未知'} 安装后检测到 {len(collected)} 个 Zed 崩溃日志，"
+                    f"已保存到 {dest_dir}"
+                )
+            return collected
+
+        except Exception as e:
+            self.logger.warning(f"收集崩溃日志失败: {e}")
+            return []
+
+    def _file_sha256(self, path: Path) -> str:
+        """Calculate the SHA-256 of a file"""
+        sha256 = hashlib.sha256()
+        with open(path, 'rb') as f:
+            for chunk in iter(lambda: f.read(1024 * 1024), b''):
+                sha256.update(chunk)
+        return sha256.hexdigest()
+
+    def _record_history(self, action: str, result: UpdateResult,
+                        previous_version: Optional[str], backup_path: Optional[Path],
+                        source: Optional[str] = None) -> None:
+        """Record an install or rollback in the update history"""
+        self.history.record(HistoryEntry(
+            action=action,
+            success=result.success,
+            version=result.version,
+            previous_version=previous_version,
+            message=result.message,
+            backup_path=str(backup_path) if backup_path else None,
+            source=source
+        ))
+        self._record_metric(self.metrics.record_install, action, result.success)
+        self._audit(action, result.success, version=result.version, previous_version=previous_version,
+                    message=result.message, source=source)
+
+    def _audit(self, action: str, success: bool, **details: Any) -> None:
+        self.audit.record(AuditEntry(action=action, success=success, details=details))
+
+    def get_metrics(self) -> str:
+        """Prometheus metrics, with the rate limits last reported by the sources"""
+        return self.metrics.render(
+            [(source.describe(), source.get_rate_limit_status()) for source in self.sources]
+        )
+
+    def _record_metric(self, record: Callable[..., None], *args: Any) -> None:
+        """Count something in metrics and refresh metrics_file"""
+        record(*args)
+        target = self.config.get('metrics_file', '')
+        if not target:
+            return
+        try:
+            target = Path(target).expanduser()
+            target.parent.mkdir(parents=True, exist_ok=True)
+            # The textfile collector must never see a half-written file
+            tmp_file = target.with_name(f".{target.name}.tmp")
+            tmp_file.write_text(self.get_metrics(), encoding='utf-8')
+            tmp_file.replace(target)
+        except OSError as e:
+            self.logger.warning(f"写入指标文件失败: {e}")
+
+    def _temp_dir(self) -> Optional[Path]:
+        """The configured temp_dir, created if missing, None for the system temp directory"""
+        temp_dir = self.config.get_temp_dir()
+        if temp_dir:
+            temp_dir.mkdir(parents=True, exist_ok=True)
+        return temp_dir
+
+    def _verify_install_file(self, path: Path) -> None:
+        """Make sure a file is fit to be installed, raise InstallationError if not"""
+        if not path.is_file():
+            raise InstallationError(f"更新文件不存在: {path}")
+        if path.stat().st_size == 0:
+            raise InstallationError(f"更新文件为空: {path}")
+
+        detected = detect_file_type(path)
+        if detected not in EXECUTABLE_TYPES:
+            raise InstallationError(f"更新文件不是可执行文件 (检测到: {detected}): {path}")
+
+    def _replace_executable(
+        self,
+        staged_path: Path,
+        zed_path: Path,
+        conflict_resolver: Optional[Callable[[LockConflict], str]] = None,
+        journal_entry: Optional[JournalEntry] = None
+    ) -> str:
+        """Move the staged file over the install path, coping with a locked target
+
+        Windows refuses to overwrite an executable that is still running.
+        Replacement is retried with backoff first. If the file stays locked
+        conflict_resolver decides how to go on; without one the running
+        binary is renamed aside (NTFS allows renaming a running image) and,
+        failing that, replacement is deferred until Zed exits.
+
+        Returns the method that was used: "replaced", "renamed_running"
+        or "scheduled".
+        """
+        last_error = None
+        delay = self.REPLACE_RETRY_DELAY
+        for attempt in range(self.REPLACE_RETRIES):
+            try:
+                os.replace(staged_path, zed_path)
+                return "replaced"
+            except OSError as e:
+                if not self._is_file_locked_error(e):
+                    raise
+                self.logger.warning(
+                    f"目标文件被占用，{delay:.1f} 秒后重试 ({attempt + 1}/{self.REPLACE_RETRIES}): {e}"
+                )
+                time.sleep(delay)
+                delay *= 2
+                last_error = e
+
+        while conflict_resolver:
+            conflict = self.describe_lock_conflict(zed_path, last_error)
+            choice = conflict_resolver(conflict)
+            self.logger.info(f"文件占用处理方式: {choice}")
+
+            if choice == 'schedule':
+                self._schedule_replace_on_exit(staged_path, zed_path)
+                return "scheduled"
+            if choice == 'cancel':
+                raise InstallationError(f"目标文件被占用，安装已取消: {zed_path}")
+            if choice == 'force_close':
+                self._kill_processes(conflict.processes)
+            elif choice != 'retry':
+                raise InstallationError(f"未知的处理方式: {choice}")
+
+            try:
+                os.replace(staged_path, zed_path)
+                return "replaced"
+            except OSError as e:
+                if not self._is_file_locked_error(e):
+                    raise
+                last_error = e
+
+        aside_path = zed_path.with_name(f".{zed_path.name}.old-{int(time.time())}")
+        if journal_entry:
+            # Recorded first: for a moment the install path is empty
+            self.journal.update(journal_entry, step='old_moved', aside=str(aside_path))
+        try:
+            os.rename(zed_path, aside_path)
+        except OSError as e:
+            if not self._is_file_locked_error(e):
+                raise
+            self.logger.warning(f"无法重命名正在运行的文件，将在 Zed 退出后替换: {e}")
+            self._schedule_replace_on_exit(staged_path, zed_path)
+            return "scheduled"
+
+        try:
+            os.replace(staged_path, zed_path)
+        except OSError:
+            os.rename(aside_path, zed_path)
+            raise
+
+        self.logger.info(f"已将运行中的文件移至 {aside_path.name}，下次清理时删除")
+        return "renamed_running"
+
+    def describe_lock_conflict(self, zed_path: Path, error: Optional[OSError] = None) -> LockConflict:
+        """Collect which processes hold the install target"""
+        target = os.path.normcase(str(zed_path.resolve()))
+        holders = []
+        for proc in self._find_zed_processes():
+            try:
+                if os.path.normcase(str(Path(proc.info['exe']).resolve())) == target:
+                    holders.append({'pid': proc.pid, 'name': proc.info['name'], 'exe': proc.info['exe']})
+            except (OSError, KeyError, TypeError):
+                continue
+
+        return LockConflict(file_path=zed_path, processes=holders, error=str(error or ""))
+
+    def _kill_processes(self, processes: List[Dict[str, Any]]) -> None:
+        """Forcefully end the given processes"""
+        for info in processes:
+            try:
+                proc = psutil.Process(info['pid'])
+                self.logger.info(f"强制结束进程: {info['pid']} ({info['name']})")
+                proc.kill()
+                proc.wait(timeout=5)
+            except (psutil.NoSuchProcess, psutil.TimeoutExpired):
+                continue
+            except psutil.AccessDenied as e:
+                self.logger.warning(f"无权结束进程 {info['pid']}: {e}")
+
+    def _is_file_locked_error(self, error: OSError) -> bool:
+        """Check if an OSError means the file is in use by another process"""
+        return (isinstance(error, PermissionError) or
+                getattr(error, 'winerror', None) in self.LOCKED_WINERRORS)
+
+    def _schedule_replace_on_exit(self, staged_path: Path, zed_path: Path) -> None:
+        """Apply a staged install once all Zed processes have exited
+
+        The staged file and the journal entry stay until the file is in
+        place, so the next start can still finish the job if this process
+        exits first.
+        """
+        self._after_zed_exit(lambda: self._apply_staged(staged_path, zed_path))
+
+    def _install_staged_on_exit(self, version: str) -> None:
+        """Install a prefetched update as soon as the user closes Zed
+
+        Nothing is closed for it; when Zed is not running the update is
+        installed right away. Zed is not restarted afterwards.
+        """
+        with self._staged_exit_lock:
+            if self._staged_exit_version == version:
+                return
+            self._staged_exit_version = version
+
+        def install():
+            with self._staged_exit_lock:
+                if self._staged_exit_version != version:
+                    return
+                self._staged_exit_version = None
+            if self._shutdown.is_set():
+                return
+            self.logger.info(f"Zed 已退出，开始安装预下载的版本 {version}")
+            result = self.check_and_update(skip_restart=True)
+            if not result.success:
+                self.logger.error(f"Zed 退出后安装失败: {result.message}")
+
+        self._after_zed_exit(install)
+
+    def _after_zed_exit(self, action: Callable[[], Any]) -> None:
+        """Run action in the background once all Zed processes have exited
+
+        Gives up when the updater shuts down; a staged install is then
+        applied on the next start.
+        """
+        def wait_and_run():
+            processes = self._find_zed_processes()
+            while processes:
+                if self._shutdown.is_set():
+                    self.logger.info("更新程序正在退出，停止等待 Zed 退出")
+                    return
+                _, processes = psutil.wait_procs(processes, timeout=self.EXIT_POLL_SECONDS)
+                if not processes:
+                    # A window opened while waiting keeps Zed running
+                    processes = self._find_zed_processes()
+            try:
+                action()
+            except Exception as e:
+                self.logger.error(f"Zed 退出后的操作失败: {e}")
+
+        thread = threading.Thread(target=wait_and_run, daemon=True)
+        self._exit_watchers.append(thread)
+        thread.start()
+
+    def wait_for_deferred_installs(self, timeout: Optional[float] = None) -> bool:
+        """Block until installs waiting for Zed to exit are done
+
+        Returns:
+            False if some are still waiting after timeout
+        """
+        deadline = time.time() + timeout if timeout is not None else None
+        for thread in list(self._exit_watchers):
+            thread.join(None if deadline is None else max(deadline - time.time(), 0))
+        self._exit_watchers = [thread for thread in self._exit_watchers if thread.is_alive()]
+        return not self._exit_watchers
+
+    def recover_interrupted_installs(self) -> List[str]:
+        """Complete or roll back installs the journal shows were cut short
+
+        A fully staged file whose hash matches the journal is installed,
+        anything less is discarded and the previous executable restored.
+
+        Returns:
+            One message per recovered install
+        """
+        messages = []
+        for entry in self.journal.pending():
+            zed_path = Path(entry.target)
+            staged_path = Path(entry.staged)
+            aside_path = Path(entry.aside) if entry.aside else None
+            staged_ok = (entry.step in ('staged', 'old_moved') and staged_path.exists() and
+                         self._file_sha256(staged_path) == entry.staged_sha256)
+            keep_open = False
+
+            try:
+                if (entry.step in ('staged', 'old_moved') and not staged_path.exists() and
+                        zed_path.exists() and self._file_sha256(zed_path) == entry.staged_sha256):
+                    # Crashed after the new file was placed
+                    outcome, success = "安装已完成", True
+                elif aside_path and aside_path.exists() and not zed_path.exists():
+                    if staged_ok:
+                        os.replace(staged_path, zed_path)
+                        outcome, success = "已完成中断的安装", True
+                    else:
+                        os.rename(aside_path, zed_path)
+                        if staged_path.exists():
+                            staged_path.unlink()
+                        outcome, success = "已恢复中断前的版本", True
+                elif staged_ok:
+                    # Same file a deferred install leaves, applied like one
+                    applied = self._apply_staged(staged_path, zed_path)
+                    outcome = "已完成中断的安装" if applied else "中断的安装已暂存，将在 Zed 退出后应用"
+                    success = True
+                    if not applied:
+                        # The entry stays open until the file is in place
+                        keep_open = True
+                        self._schedule_replace_on_exit(staged_path, zed_path)
+                else:
+                    if staged_path.exists():
+                        staged_path.unlink()
+                    outcome, success = "已撤销中断的安装，当前版本未改变", True
+            except OSError as e:
+                outcome, success = f"恢复中断的安装失败: {e}", False
+
+            message = f"{zed_path}: {outcome}"
+            (self.logger.info if success else self.logger.error)(message)
+            self.history.record(HistoryEntry(
+                action="recover",
+                success=success,
+                version=self.get_current_version(zed_path) if success else None,
+                message=message,
+                backup_path=entry.backup
+            ))
+            self._record_metric(self.metrics.record_install, "recover", success)
+            self._audit("recover", success, message=message)
+            if success and not keep_open:
+                self.journal.finish(entry)
+            messages.append(message)
+        return messages
+
+    def apply_pending_install(self) -> bool:
+        """Finish installs that were deferred because Zed was running
+
+        Returns:
+            True if any install path was updated
+        """
+        applied = False
+        for zed_path in self.config.get_install_paths():
+            applied = self._apply_pending_install(zed_path) or applied
+        return applied
+
+    def _apply_pending_install(self, zed_path: Path) -> bool:
+        return self._apply_staged(zed_path.with_name(f".{zed_path.name}.new"), zed_path)
+
+    @critical_section
+    def _apply_staged(self, staged_path: Path, zed_path: Path) -> bool:
+        """Move a staged install into place and close its journal entry"""
+        if not staged_path.exists():
+            return False
+
+        delay = self.REPLACE_RETRY_DELAY
+        for attempt in range(self.REPLACE_RETRIES):
+            try:
+                os.replace(staged_path, zed_path)
+                self.logger.info(f"已应用延迟的更新: {zed_path}")
+                for entry in self.journal.pending():
+                    if entry.target == str(zed_path):
+                        self.journal.finish(entry)
+                return True
+            except OSError as e:
+                if not self._is_file_locked_error(e):
+                    self.logger.error(f"应用延迟的更新失败: {e}")
+                    return False
+                time.sleep(delay)
+                delay *= 2
+
+        self.logger.warning("目标文件仍被占用，延迟的更新将在下次启动时重试")
+        return False
+
+    def _find_zed_processes(self) -> list:
+        """Find running Zed processes"""
+        zed_processes = []
+        for proc in psutil.process_iter(['pid', 'name', 'exe']):
+            try:
+                if (proc.info['name'] and 'zed' in proc.info['name'].lower() and
+                    proc.info['exe'] and Path(proc.info['exe']).name.lower().startswith('zed')):
+                    zed_processes.append(proc)
+            except (psutil.NoSuchProcess, psutil.AccessDenied):
+                continue
+        return zed_processes
+
+    def get_zed_process_status(self, sample_seconds: float = 0.5) -> List[Dict[str, Any]]:
+        """CPU, memory and uptime of every running Zed process
+
+        For telling after an update whether Zed came up and stays healthy.
+        CPU use is measured over sample_seconds, as a percentage of one core.
+
+        Returns:
+            One dict per process with pid, exe, status, cpu_percent,
+            memory_rss (bytes) and uptime_seconds
+        """
+        processes = self._find_zed_processes()
+        for proc in processes:
+            try:
+                proc.cpu_percent(None)  # Starts the measurement
+            except (psutil.NoSuchProcess, psutil.AccessDenied):
+                continue
+        if processes:
+            time.sleep(sample_seconds)
+
+        statuses = []
+        for proc in processes:
+            try:
+                with proc.oneshot():
+                    statuses.append({
+                        'pid': proc.pid,
+                        'exe': proc.info.get('exe'),
+                        'status': proc.status(),
+                        'cpu_percent': proc.cpu_percent(None),
+                        'memory_rss': proc.memory_info().rss,
+                        'uptime_seconds': max(time.time() - proc.create_time(), 0.0),
+                    })
+            except (psutil.NoSuchProcess, psutil.AccessDenied):
+                continue
+        return statuses
+
+    def stop_zed(self, timeout: Optional[float] = None, force: Optional[bool] = None) -> ZedStopResult:
+        """停止所有Zed进程
+
+        Zed is asked to close first so it can save its state (WM_CLOSE on
+        Windows, SIGTERM elsewhere). Processes still running after timeout
+        seconds (zed_close_timeout by default) are killed, unless force
+        (zed_force_kill by default) is off.
+        """
+        if timeout is None:
+            timeout = self.config.get('zed_close_timeout', 10)
+        if force is None:
+            force = self.config.get('zed_force_kill', True)
+        result = ZedStopResult()
+
+        try:
+            zed_processes = self._find_zed_processes()
 
             for proc in zed_processes:
                 try:
-                    self.logger.info(f"停止Zed进程: {proc.pid}")
-                    proc.terminate()
-                    proc.wait(timeout=10)
-                except psutil.TimeoutExpired:
-                    proc.kill()  # 强制终止
+                    self.logger.info(f"请求关闭Zed进程: {proc.pid}")
+                    self._request_close(proc)
+                except psutil.NoSuchProcess:
+                    continue
+                except Exception as e:
+                    self.logger.warning(f"关闭进程 {proc.pid} 失败: {e}")
+
+            gone, alive = psutil.wait_procs(zed_processes, timeout=timeout)
+            result.closed = [proc.pid for proc in gone]
+            if alive and not force:
+                self.logger.warning(f"{len(alive)} 个Zed进程在 {timeout} 秒内未退出，未强制终止")
+                result.running = [proc.pid for proc in alive]
+                return result
+
+            for proc in alive:
+                try:
+                    self.logger.warning(f"Zed进程 {proc.pid} 在 {timeout} 秒内未退出，强制终止")
+                    proc.kill()
+                except psutil.NoSuchProcess:
+                    continue
                 except Exception as e:
                     self.logger.warning(f"停止进程 {proc.pid} 失败: {e}")
+            if alive:
+                killed, survivors = psutil.wait_procs(alive, timeout=5)
+                result.killed = [proc.pid for proc in killed]
+                result.running = [proc.pid for proc in survivors]
 
         except Exception as e:
             self.logger.warning(f"停止Zed进程时出错: {e}")
+        return result
+
+    def _request_close(self, proc) -> None:
+        """Ask a process to exit without killing it"""
+        if platform.system() == "Windows":
+            # psutil's terminate() is TerminateProcess on Windows, taskkill
+            # without /F posts WM_CLOSE to the windows instead
+            subprocess.run(
+                ['taskkill', '/PID', str(proc.pid)],
+                capture_output=True,
+                timeout=10,
+                creationflags=subprocess.CREATE_NO_WINDOW
+            )
+        else:
+            proc.terminate()  # SIGTERM
 
-    def check_and_update(self, progress_callback: Optional[Callable[[float, str], None]] = None) -> UpdateResult:
-        """检查更新并执行安装"""
+    def prefetch_update(self, progress_callback: Optional[Callable[[float, str], None]] = None) -> UpdateResult:
+        """检查并预先下载更新，但不安装
+
+        下载的文件保留在临时目录中，之后安装时 download_update 会直接复用它。
+        """
         try:
-            # 检查更新
             release_info = self.check_for_updates()
             if not release_info:
+                return self._no_update_result()
+
+            size_error = self.check_asset_size(release_info.size or 0)
+            if size_error:
                 return UpdateResult(
-                    success=True,
-                    message="没有可用的更新"
+                    success=False,
+                    message=size_error,
+                    version=release_info.version,
+                    error_code="ASSET_TOO_LARGE"
                 )
 
+            download_path = self._download_release(release_info, progress_callback)
+            if not download_path:
+                return UpdateResult(
+                    success=False,
+                    message="预下载失败",
+                    version=release_info.version,
+                    error_code="DOWNLOAD_FAILED"
+                )
+
+            self.logger.info(f"版本 {release_info.version} 已预下载: {download_path}")
+            if self.config.get('install_staged_on_exit', False):
+                self._install_staged_on_exit(release_info.version)
+                message = f"版本 {release_info.version} 已下载，将在 Zed 退出后安装"
+            else:
+                message = f"版本 {release_info.version} 已下载，可立即安装"
+            return UpdateResult(
+                success=True,
+                message=message,
+                version=release_info.version,
+                download_path=download_path
+            )
+
+        except Exception as e:
+            error_msg = f"预下载更新失败: {e}"
+            self.logger.error(error_msg)
+            return UpdateResult(
+                success=False,
+                message=error_msg,
+                error_code="PREFETCH_FAILED"
+            )
+
+    def check_and_update(
+        self,
+        progress_callback: Optional[Callable[[float, str], None]] = None,
+        ignore_size_limit: bool = False,
+        skip_backup: bool = False,
+        skip_restart: bool = False,
+        dry_run: bool = False,
+        tag: Optional[str] = None
+    ) -> UpdateResult:
+        """检查更新并执行安装
+
+        Runs the whole pipeline: check, download, verify, stop Zed, backup, install
+        and restart. Progress messages are prefixed with the current stage.
+
+        Args:
+            progress_callback: Progress callback function
+            ignore_size_limit: Download even if the asset exceeds max_asset_size_mb
+            skip_backup: Don't back up the current version before installing
+            skip_restart: Don't start Zed afterwards, even if auto_start_after_update is set
+            dry_run: Download and verify, then only report what the install
+                would do. Zed is neither stopped nor restarted.
+            tag: Install this release instead of the latest one, even if it
+                is older than the installed version
+        """
+        stage = [self.PIPELINE_STAGES[0]]
+
+        def report(start: float, end: float):
+            def callback(progress: float, message: str) -> None:
+                if progress_callback:
+                    index = self.PIPELINE_STAGES.index(stage[0]) + 1
+                    progress_callback(start + progress * (end - start) / 100,
+                                      f"[{index}/{len(self.PIPELINE_STAGES)}] {message}")
+            return callback
+
+        try:
+            # 检查更新，指定版本时直接获取该版本 (允许降级)
+            if tag:
+                report(0, 5)(0, f"正在获取版本 {tag}...")
+                release_info = self.get_release(tag)
+                if not release_info:
+                    return UpdateResult(
+                        success=False,
+                        message=f"找不到版本 {tag}",
+                        error_code="RELEASE_NOT_FOUND"
+                    )
+            else:
+                report(0, 5)(0, "正在检查更新...")
+                release_info = self.check_for_updates()
+                if not release_info:
+                    return self._no_update_result()
+
             # 下载更新
-            if progress_callback:
-                progress_callback(0, "开始下载更新...")
+            stage[0] = 'download'
+            report(5, 75)(0, "开始下载更新...")
 
-            download_path = self.download_update(release_info, progress_callback)
+            size_error = None if ignore_size_limit else self.check_asset_size(release_info.size or 0)
+            if size_error:
+                return UpdateResult(
+                    success=False,
+                    message=size_error,
+                    version=release_info.version,
+                    error_code="ASSET_TOO_LARGE"
+                )
+
+            patched_path = self.download_delta_update(release_info, report(5, 75))
+            download_path = patched_path or self.download_update(
+                release_info, report(5, 75), ignore_size_limit
+            )
+            if self._shutdown.is_set():
+                return self._shutdown_result(release_info.version)
             if not download_path:
                 return UpdateResult(
                     success=False,
@@ -423,16 +2452,55 @@ class ZedUpdater:
                     error_code="DOWNLOAD_FAILED"
                 )
 
-            # 安装更新
-            if progress_callback:
-                progress_callback(80, "正在安装更新...")
+            # 校验下载，增量补丁的结果已经按哈希校验过
+            stage[0] = 'verify'
+            report(75, 80)(0, "正在校验更新文件...")
+            if not patched_path and not self.verify_download(download_path, release_info):
+                return UpdateResult(
+                    success=False,
+                    message="下载的文件校验失败",
+                    version=release_info.version,
+                    error_code="VERIFY_FAILED"
+                )
 
-            install_result = self.install_update(download_path)
+            if dry_run:
+                stage[0] = 'install'
+                return self.install_update(download_path, report(85, 100),
+                                           skip_backup=skip_backup, dry_run=True)
+
+            # 停止正在运行的 Zed，退出时安装模式下等待用户自己关闭
+            if self._shutdown.is_set():
+                return self._shutdown_result(release_info.version)
+            stage[0] = 'stop'
+            install_on_exit = self.config.get('install_on_exit', False)
+            stop_result = None
+            if install_on_exit:
+                report(80, 85)(100, "将在 Zed 退出后安装")
+            else:
+                report(80, 85)(0, "正在关闭 Zed...")
+                stop_result = self.stop_zed()
+                if stop_result.closed or stop_result.killed:
+                    report(80, 85)(100, "Zed 已关闭")
+
+            # 备份和安装，备份是 install_update 的一部分
+            stage[0] = 'install'
+            install_result = self.install_update(
+                download_path, report(85, 98), skip_backup=skip_backup,
+                expected_version=release_info.version,
+                defer_until_exit=install_on_exit,
+                source=release_info.source,
+                stop_result=stop_result
+            )
 
             # 如果配置了自动启动且安装成功
-            if (install_result.success and
-                self.config.get('auto_start_after_update')):
-                self.start_zed()
+            stage[0] = 'restart'
+            if (install_result.success and not skip_restart and
+                    install_result.install_method != "scheduled" and
+                    self.config.get('auto_start_after_update')):
+                report(98, 100)(0, "正在启动 Zed...")
+                process = self._launch_zed()
+                if process and self.config.get('crash_watchdog_seconds', 0) > 0:
+                    self._watch_for_crash(process, install_result.version)
 
             return install_result
 
@@ -445,39 +2513,203 @@ class ZedUpdater:
                 error_code="UPDATE_FAILED"
             )
 
-    def start_zed(self) -> bool:
-        """启动Zed应用程序"""
-        zed_path = self.config.get('zed_install_path', 'D:\\Zed.exe')
+    def _shutdown_result(self, version: Optional[str]) -> UpdateResult:
+        self.logger.info("正在退出，更新未安装")
+        return UpdateResult(
+            success=False,
+            message="程序正在退出，更新已取消",
+            version=version,
+            error_code="CANCELLED"
+        )
+
+    def start_zed(self, profile: Optional[str] = None) -> Optional[int]:
+        """启动Zed应用程序
+
+        Zed gets its own session (or process group and no console on
+        Windows), so it keeps running when the updater exits or its
+        console is closed. Arguments, working directory and extra
+        environment come from zed_launch_args, zed_launch_dir and
+        zed_launch_env.
+
+        Args:
+            profile: Name in zed_launch_profiles, for e.g. a preview build
+                next to the stable one. Its path, args and dir replace the
+                defaults, its env is added, and user_data_dir is passed
+                as --user-data-dir so the builds don't share settings.
+
+        Returns:
+            PID of the started Zed, None if it could not be started
+        """
+        process = self._launch_zed(profile)
+        return process.pid if process else None
+
+    def _launch_zed(self, profile: Optional[str] = None) -> Optional[Union[subprocess.Popen, psutil.Process]]:
+        settings: Dict[str, Any] = {}
+        if profile:
+            profiles = self.config.get('zed_launch_profiles', {})
+            if profile not in profiles:
+                self.logger.error(f"未知的启动配置: {profile}，可用: {', '.join(profiles) or '无'}")
+                return None
+            settings = profiles[profile]
 
-        if not zed_path or not Path(zed_path).exists():
+        zed_path = settings.get('path') or self.config.get('zed_install_path')
+        if not zed_path or not Path(zed_path).expanduser().exists():
             self.logger.error(f"Zed可执行文件不存在: {zed_path}")
-            return False
+            return None
+        zed_path = str(Path(zed_path).expanduser())
+
+        # Elevated for an install into a protected location, the editor must not inherit that
+        unelevate = is_admin() and self.config.get('zed_launch_unelevated', True)
+        user = desktop_user() if unelevate else None
+        home = Path(user.home) if user else Path.home()
+
+        args = list(settings.get('args', self.config.get('zed_launch_args', [])))
+        if settings.get('user_data_dir'):
+            user_data_dir = Path(settings['user_data_dir']).expanduser()
+            if not user_data_dir.exists():
+                user_data_dir.mkdir(parents=True)
+                if user:
+                    os.chown(user_data_dir, user.uid, user.gid)
+            args = ['--user-data-dir', str(user_data_dir)] + args
+
+        launch_dir = Path(settings.get('dir') or self.config.get('zed_launch_dir', '') or home).expanduser()
+        extra_env: Dict[str, str] = {}
+        for assignment in list(self.config.get('zed_launch_env', [])) + list(settings.get('env', [])):
+            key, sep, value = assignment.partition('=')
+            if not sep or not key:
+                self.logger.warning(f"忽略格式错误的环境变量 (应为 KEY=VALUE): {assignment}")
+                continue
+            extra_env[key] = value
+        env = dict(os.environ)
+        if user:
+            env.update(HOME=user.home, USER=user.name, LOGNAME=user.name)
+        env.update(extra_env)
+        cwd = str(launch_dir) if launch_dir.is_dir() else None
+
+        options: Dict[str, Any] = {}
+        if platform.system() == "Windows":
+            if unelevate:
+                try:
+                    pid = ElevationHelper().start_as_desktop_user([zed_path] + args, cwd, extra_env)
+                    self.logger.info(f"Zed 已以桌面用户身份启动，PID {pid}")
+                    return psutil.Process(pid)
+                except (ElevationError, OSError, psutil.Error) as e:
+                    self.logger.error(f"无法以普通用户身份启动 Zed，为避免以管理员身份运行编辑器未启动 Zed: {e} "
+                                      f"(设置 zed_launch_unelevated 为 false 可直接启动)")
+                    return None
+            options['creationflags'] = (subprocess.DETACHED_PROCESS |
+                                        subprocess.CREATE_NEW_PROCESS_GROUP)
+        else:
+            options['start_new_session'] = True
+            if user:
+                options['preexec_fn'] = drop_privileges(user)
 
         try:
-            self.logger.info(f"启动Zed: {zed_path}")
-            subprocess.Popen([zed_path])
-            return True
+            self.logger.info(f"启动Zed: {zed_path}" + (f" (启动配置 {profile})" if profile else "")
+                             + (f"，用户 {user.name}" if user else ""))
+            process = subprocess.Popen(
+                [zed_path] + args,
+                cwd=cwd,
+                env=env,
+                stdin=subprocess.DEVNULL,
+                stdout=subprocess.DEVNULL,
+                stderr=subprocess.DEVNULL,
+                close_fds=True,
+                **options
+            )
+            self.logger.info(f"Zed 已启动，PID {process.pid}")
+            return process
         except Exception as e:
             self.logger.error(f"启动Zed失败: {e}")
-            return False
+            return None
+
+    def add_crash_callback(self, callback: Callable[[str, Optional[UpdateResult]], None]) -> None:
+        """Call callback(message, rollback_result) when Zed crashes soon after an update
+
+        rollback_result is None unless rollback_on_crash is set.
+        """
+        if callback not in self._crash_callbacks:
+            self._crash_callbacks.append(callback)
+
+    def _watch_for_crash(self, process: Union[subprocess.Popen, psutil.Process], version: Optional[str]) -> None:
+        """Treat a failing exit within crash_watchdog_seconds as a bad update
+
+        An exit whose code can't be read is not a crash. Runs in the
+        background; wait_for_crash_watch() waits for it.
+        """
+        seconds = self.config.get('crash_watchdog_seconds', 0)
+
+        def watch():
+            started = time.monotonic()
+            try:
+                exit_code = process.wait(timeout=seconds)
+            except (subprocess.TimeoutExpired, psutil.TimeoutExpired):
+                self.logger.info(f"Zed 更新后已正常运行 {seconds} 秒")
+                return
+            if exit_code == 0:
+                # Closed by the user, or a launcher that hands over to the editor
+                return
+            if exit_code is None:
+                # psutil can't read the exit code of a process that isn't our child,
+                # e.g. Zed started unelevated through the desktop shell
+                self.logger.info("Zed 已退出，无法取得退出码，不视为崩溃")
+                return
+
+            message = (f"版本 {version or '未知'} 更新后 Zed 在 {time.monotonic() - started:.0f} 秒内"
+                       f"异常退出 (退出码 {exit_code})，可能是有问题的更新")
+            self.logger.error(message)
+            self.history.record(HistoryEntry(action="crash", success=False, version=version, message=message))
+            self._audit("crash", False, version=version, exit_code=exit_code)
+
+            rollback_result = None
+            if self.config.get('rollback_on_crash', False):
+                self.logger.warning("正在自动回滚到上一个备份...")
+                rollback_result = self.rollback()
+                if rollback_result.success:
+                    self._launch_zed()
+            for callback in list(self._crash_callbacks):
+                try:
+                    callback(message, rollback_result)
+                except Exception as e:
+                    self.logger.error(f"Crash callback failed: {e}")
+
+        thread = threading.Thread(target=watch, daemon=True)
+        self._crash_watchers.append(thread)
+        thread.start()
+
+    def wait_for_crash_watch(self, timeout: Optional[float] = None) -> None:
+        """Block until the watchdog of the last restart has decided"""
+        for thread in list(self._crash_watchers):
+            thread.join(timeout)
+        self._crash_watchers = [thread for thread in self._crash_watchers if thread.is_alive()]
 
     def cleanup_temp_files(self) -> None:
         """清理临时文件"""
         try:
-            temp_dir = self.config.get_temp_dir()
-            if temp_dir.exists():
-                # 删除1天前的临时文件
-                current_time = time.time()
-                max_age = 24 * 60 * 60  # 1天
-
-                for file_path in temp_dir.glob("*"):
-                    if file_path.is_file():
-                        if current_time - file_path.stat().st_mtime > max_age:
-                            try:
+            # 删除1天前的临时文件，只删更新器自己下载或解压的文件
+            current_time = time.time()
+            max_age = 24 * 60 * 60  # 1天
+
+            for download_dir in self.config.get_download_dirs():
+                for file_path in download_dir.glob("zed_update_*"):
+                    if current_time - file_path.stat().st_mtime > max_age:
+                        try:
+                            if file_path.is_dir():
+                                shutil.rmtree(file_path)
+                            else:
                                 file_path.unlink()
-                                self.logger.debug(f"清理临时文件: {file_path}")
-                            except Exception as e:
-                                self.logger.warning(f"清理临时文件失败: {file_path}")
+                            self.logger.debug(f"清理临时文件: {file_path}")
+                        except Exception as e:
+                            self.logger.warning(f"清理临时文件失败: {file_path}")
+
+            # 删除安装时移走的旧版本文件（仍在运行时会删除失败，下次再试）
+            zed_path = Path(self.config.get('zed_install_path'))
+            for old_file in zed_path.parent.glob(f".{zed_path.name}.old-*"):
+                try:
+                    old_file.unlink()
+                    self.logger.debug(f"清理旧版本文件: {old_file}")
+                except OSError:
+                    pass
 
         except Exception as e:
             self.logger.warning(f"清理临时文件失败: {e}")
\ No newline at end of file
@@ -14,7 +14,7 @@ from PyQt5.QtWidgets import (
     QGroupBox, QSystemTrayIcon, QMenu, QAction, QMessageBox,
     QSplitter, QScrollArea
 )
-from PyQt5.QtCore import Qt, QTimer, pyqtSignal
+from PyQt5.QtCore import Qt, QTimer, QFileSystemWatcher, pyqtSignal
 from PyQt5.QtGui import QIcon, QFont
 
 from ..core.config import ConfigManager
@@ -36,6 +36,7 @@ class MainWindow(QMainWindow):
     # Signals
     update_progress = pyqtSignal(float, str)
     update_completed = pyqtSignal(bool, str)
+    update_crashed = pyqtSignal(str)
 
     def __init__(self, config: ConfigManager, updater: ZedUpdater,
                  scheduler: UpdateScheduler):
@@ -59,6 +60,7 @@ class MainWindow(QMainWindow):
         self.setup_tray_icon()
         self.setup_connections()
         self.setup_timers()
+        self.setup_config_watcher()
 
         # Setup notifications
         if self.tray_icon:
@@ -316,6 +318,10 @@ class MainWindow(QMainWindow):
         # Connect updater signals
         self.update_progress.connect(self.on_update_progress)
         self.update_completed.connect(self.on_update_completed)
+        self.update_crashed.connect(self.notification_service.show_update_crashed)
+        # The watchdog runs in its own thread, so hand over through the signal
+        self.updater.add_crash_callback(lambda message, rollback_result: self.update_crashed.emit(
+            message + (f"\n{rollback_result.message}" if rollback_result else "")))
 
         # Connect scheduler callbacks
         self.scheduler.add_update_callback(self.on_scheduler_update)
@@ -332,6 +338,40 @@ class MainWindow(QMainWindow):
         self.log_timer.timeout.connect(self.refresh_log)
         self.log_timer.start(5000)  # Every 5 seconds
 
+    def setup_config_watcher(self):
+        """Reload the config file when it is edited outside the GUI"""
+        self.config_watcher = QFileSystemWatcher([str(self.config.config_file)])
+        self.config_watcher.fileChanged.connect(self.on_config_file_changed)
+
+    def on_config_file_changed(self, path: str):
+        """Handle a change of the watched config file"""
+        # Saving by replacing the file drops it from the watch list, and
+        # the new file may not be there yet
+        QTimer.singleShot(200, self.reload_config)
+
+    def reload_config(self):
+        """Re-read the config file and watch it again"""
+        path = str(self.config.config_file)
+        if path not in self.config_watcher.files() and Path(path).exists():
+            self.config_watcher.addPath(path)
+        changed = self.config.reload_if_changed()
+        if changed:
+            self.on_config_reloaded(changed)
+
+    def on_config_reloaded(self, changed: list):
+        """Reflect a config reloaded from disk, the updater and scheduler follow by themselves"""
+        try:
+            if 'auto_check_enabled' in changed:
+                if self.config.get('auto_check_enabled'):
+                    self.scheduler.start()
+                elif self.scheduler.is_running():
+                    self.scheduler.stop()
+            if self.settings_dialog:
+                self.settings_dialog.load_settings()
+            self.statusBar().showMessage("配置文件已在外部修改，已重新加载", 5000)
+        except Exception as e:
+            self.logger.error(f"Failed to apply reloaded config: {e}")
+
     def load_settings(self):
         """Load settings from configuration"""
         try:
@@ -490,7 +530,10 @@ class MainWindow(QMainWindow):
     def on_scheduler_update(self, update_available: bool, result: Optional[UpdateResult]):
         """Handle scheduler update callback"""
         if update_available and result:
-            self.notification_service.show_update_available(result.version or "最新版")
+            if result.download_path:
+                self.notification_service.show_update_ready(result.version or "最新版")
+            else:
+                self.notification_service.show_update_available(result.version or "最新版")
 
     def closeEvent(self, event):
         """Handle window close event"""
@@ -501,6 +544,9 @@ class MainWindow(QMainWindow):
             event.ignore()
         else:
             # Cleanup
+            # Stops a scheduled check's download, an install replacing files finishes
+            if not self.updater.shutdown(timeout=60):
+                self.logger.warning("Exiting while an install is still running")
             if self.scheduler.is_running():
                 self.scheduler.stop()
 
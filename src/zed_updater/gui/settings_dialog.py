@@ -65,6 +65,11 @@ class SettingsDialog(QDialog):
         self.github_repo_edit.setPlaceholderText("owner/repo")
         basic_layout.addWidget(self.github_repo_edit, 1, 1, 1, 2)
 
+        basic_layout.addWidget(QLabel("发布渠道:"), 2, 0)
+        self.release_channel_combo = QComboBox()
+        self.release_channel_combo.addItems(["stable", "prerelease", "nightly"])
+        basic_layout.addWidget(self.release_channel_combo, 2, 1, 1, 2)
+
         layout.addWidget(basic_group)
 
         # Update settings group
@@ -102,18 +107,26 @@ class SettingsDialog(QDialog):
         self.auto_install = QCheckBox("自动安装更新")
         action_layout.addWidget(self.auto_install, 1, 0, 1, 2)
 
+        self.prefetch_updates = QCheckBox("定时预下载新版本（不安装）")
+        action_layout.addWidget(self.prefetch_updates, 2, 0, 1, 2)
+
         self.auto_start_after_update = QCheckBox("更新后自动启动Zed")
-        action_layout.addWidget(self.auto_start_after_update, 2, 0, 1, 2)
+        action_layout.addWidget(self.auto_start_after_update, 3, 0, 1, 2)
 
-        action_layout.addWidget(QLabel("下载超时(秒):"), 3, 0)
+        action_layout.addWidget(QLabel("下载超时(秒):"), 4, 0)
         self.download_timeout_spin = QSpinBox()
         self.download_timeout_spin.setRange(30, 3600)
-        update_layout.addWidget(self.download_timeout_spin, 3, 1)
+        action_layout.addWidget(self.download_timeout_spin, 4, 1)
 
-        action_layout.addWidget(QLabel("重试次数:"), 4, 0)
+        action_layout.addWidget(QLabel("重试次数:"), 5, 0)
         self.retry_count_spin = QSpinBox()
         self.retry_count_spin.setRange(0, 10)
-        action_layout.addWidget(self.retry_count_spin, 4, 1)
+        action_layout.addWidget(self.retry_count_spin, 5, 1)
+
+        action_layout.addWidget(QLabel("关闭Zed等待(秒):"), 6, 0)
+        self.zed_close_timeout_spin = QSpinBox()
+        self.zed_close_timeout_spin.setRange(0, 300)
+        action_layout.addWidget(self.zed_close_timeout_spin, 6, 1)
 
         layout.addWidget(action_group)
 
@@ -143,6 +156,11 @@ class SettingsDialog(QDialog):
         self.proxy_url_edit.setPlaceholderText("http://proxy.example.com:8080")
         network_layout.addWidget(self.proxy_url_edit, 1, 1)
 
+        network_layout.addWidget(QLabel("下载大小上限(MB，0为不限):"), 2, 0)
+        self.max_asset_size_spin = QSpinBox()
+        self.max_asset_size_spin.setRange(0, 100000)
+        network_layout.addWidget(self.max_asset_size_spin, 2, 1)
+
         layout.addWidget(network_group)
 
         # UI settings group
@@ -167,11 +185,13 @@ class SettingsDialog(QDialog):
 
         # Button box
         button_box = QDialogButtonBox(
-            QDialogButtonBox.Ok | QDialogButtonBox.Cancel | QDialogButtonBox.Apply
+            QDialogButtonBox.Ok | QDialogButtonBox.Cancel | QDialogButtonBox.Apply |
+            QDialogButtonBox.RestoreDefaults
         )
         button_box.accepted.connect(self.accept)
         button_box.rejected.connect(self.reject)
         button_box.button(QDialogButtonBox.Apply).clicked.connect(self.apply_settings)
+        button_box.button(QDialogButtonBox.RestoreDefaults).clicked.connect(self.restore_defaults)
 
         main_layout.addWidget(button_box)
 
@@ -181,11 +201,12 @@ class SettingsDialog(QDialog):
             # Basic settings
             self.zed_path_edit.setText(self.config.get('zed_install_path', ''))
             self.github_repo_edit.setText(self.config.get('github_repo', ''))
+            self.release_channel_combo.setCurrentText(self.config.get_release_channel())
 
             # Update settings
             self.auto_check_enabled.setChecked(self.config.get('auto_check_enabled', True))
             self.check_interval_spin.setValue(self.config.get('check_interval_hours', 24))
-            check_time = self.config.get('check_time', '09:00')
+            check_time = self.config.get('check_time') or '09:00'
             self.check_time_edit.setTime(QTime.fromString(check_time, "hh:mm"))
             self.check_on_startup.setChecked(self.config.get('check_on_startup', True))
             self.force_download_latest.setChecked(self.config.get('force_download_latest', True))
@@ -193,9 +214,11 @@ class SettingsDialog(QDialog):
             # Action settings
             self.auto_download.setChecked(self.config.get('auto_download', True))
             self.auto_install.setChecked(self.config.get('auto_install', False))
+            self.prefetch_updates.setChecked(self.config.get('prefetch_updates', False))
             self.auto_start_after_update.setChecked(self.config.get('auto_start_after_update', True))
             self.download_timeout_spin.setValue(self.config.get('download_timeout', 300))
             self.retry_count_spin.setValue(self.config.get('retry_count', 3))
+            self.zed_close_timeout_spin.setValue(self.config.get('zed_close_timeout', 10))
 
             # Backup settings
             self.backup_enabled.setChecked(self.config.get('backup_enabled', True))
@@ -204,6 +227,7 @@ class SettingsDialog(QDialog):
             # Network settings
             self.proxy_enabled.setChecked(self.config.get('proxy_enabled', False))
             self.proxy_url_edit.setText(self.config.get('proxy_url', ''))
+            self.max_asset_size_spin.setValue(self.config.get('max_asset_size_mb', 0))
 
             # UI settings
             self.minimize_to_tray.setChecked(self.config.get('minimize_to_tray', True))
@@ -223,6 +247,7 @@ class SettingsDialog(QDialog):
             # Basic settings
             updates['zed_install_path'] = self.zed_path_edit.text()
             updates['github_repo'] = self.github_repo_edit.text()
+            updates['release_channel'] = self.release_channel_combo.currentText()
 
             # Update settings
             updates['auto_check_enabled'] = self.auto_check_enabled.isChecked()
@@ -235,9 +260,11 @@ class SettingsDialog(QDialog):
             # Action settings
             updates['auto_download'] = self.auto_download.isChecked()
             updates['auto_install'] = self.auto_install.isChecked()
+            updates['prefetch_updates'] = self.prefetch_updates.isChecked()
             updates['auto_start_after_update'] = self.auto_start_after_update.isChecked()
             updates['download_timeout'] = self.download_timeout_spin.value()
             updates['retry_count'] = self.retry_count_spin.value()
+            updates['zed_close_timeout'] = self.zed_close_timeout_spin.value()
 
             # Backup settings
             updates['backup_enabled'] = self.backup_enabled.isChecked()
@@ -246,6 +273,7 @@ class SettingsDialog(QDialog):
             # Network settings
             updates['proxy_enabled'] = self.proxy_enabled.isChecked()
             updates['proxy_url'] = self.proxy_url_edit.text()
+            updates['max_asset_size_mb'] = self.max_asset_size_spin.value()
 
             # UI settings
             updates['minimize_to_tray'] = self.minimize_to_tray.isChecked()
@@ -292,6 +320,20 @@ class SettingsDialog(QDialog):
         if self.save_settings():
             QMessageBox.information(self, "应用成功", "设置已应用")
 
+    def restore_defaults(self):
+        """Reset every setting after asking, the old file is kept as a .bak"""
+        answer = QMessageBox.question(
+            self, "恢复默认设置",
+            f"确定要把所有设置恢复为默认值吗？\n当前配置会保存为 {self.config.config_file.name}.bak"
+        )
+        if answer != QMessageBox.Yes:
+            return
+        if self.config.reset():
+            self.load_settings()
+            QMessageBox.information(self, "已恢复", "已恢复默认设置")
+        else:
+            QMessageBox.warning(self, "恢复失败", "保存配置失败")
+
     def accept(self):
         """Accept dialog and save settings"""
         if self.save_settings():
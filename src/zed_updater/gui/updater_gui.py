@@ -4,6 +4,7 @@
 Updater GUI component for Zed Updater
 """
 
+import threading
 from pathlib import Path
 from typing import Optional
 
@@ -16,7 +17,7 @@ from PyQt5.QtCore import Qt, QTimer, QThread, pyqtSignal
 from PyQt5.QtGui import QFont
 
 from ..core.config import ConfigManager
-from ..core.updater import ZedUpdater, UpdateResult
+from ..core.updater import ZedUpdater, UpdateResult, LockConflict
 from ..core.scheduler import UpdateScheduler
 from ..services.github_api import ReleaseInfo
 from ..utils.logger import get_logger
@@ -28,12 +29,16 @@ class UpdateWorker(QThread):
     progress_updated = pyqtSignal(float, str)
     update_completed = pyqtSignal(bool, str)
     version_info_received = pyqtSignal(object)  # ReleaseInfo
+    lock_conflict = pyqtSignal(object)  # LockConflict, answer with answer_conflict()
 
     def __init__(self, updater: ZedUpdater, operation: str):
         super().__init__()
         self.updater = updater
         self.operation = operation
         self.release_info = None
+        self.ignore_size_limit = False
+        self._conflict_answered = threading.Event()
+        self._conflict_choice = 'schedule'
 
     def set_release_info(self, release_info: ReleaseInfo):
         """Set release info for download/install operations"""
@@ -75,7 +80,8 @@ class UpdateWorker(QThread):
 
         download_path = self.updater.download_update(
             self.release_info,
-            lambda progress, message: self.progress_updated.emit(progress, message)
+            lambda progress, message: self.progress_updated.emit(progress, message),
+            ignore_size_limit=self.ignore_size_limit
         )
 
         if download_path:
@@ -92,14 +98,21 @@ class UpdateWorker(QThread):
         self.progress_updated.emit(0, "开始安装更新...")
 
         # Assume download was done previously - need to find the file
-        temp_dir = self.updater.config.get_temp_dir()
-        expected_file = temp_dir / f"zed_update_{self.release_info.version}.exe"
+        expected_file = self.updater.get_download_path(self.release_info)
 
         if not expected_file.exists():
             self.update_completed.emit(False, "未找到下载的文件")
             return
 
-        result = self.updater.install_update(expected_file)
+        if not self.updater.verify_download(expected_file, self.release_info):
+            self.update_completed.emit(False, "下载的文件不完整，请重新下载")
+            return
+
+        result = self.updater.install_update(
+            expected_file,
+            lambda progress, message: self.progress_updated.emit(progress, message),
+            conflict_resolver=self._resolve_conflict
+        )
 
         if result.success:
             self.progress_updated.emit(100, "安装完成")
@@ -107,6 +120,19 @@ class UpdateWorker(QThread):
         else:
             self.update_completed.emit(False, result.message)
 
+    def _resolve_conflict(self, conflict: LockConflict) -> str:
+        """Ask the GUI thread how to handle a locked file and wait for the answer"""
+        self._conflict_choice = 'schedule'
+        self._conflict_answered.clear()
+        self.lock_conflict.emit(conflict)
+        self._conflict_answered.wait()
+        return self._conflict_choice
+
+    def answer_conflict(self, choice: str):
+        """Deliver the user's choice for a pending lock conflict"""
+        self._conflict_choice = choice
+        self._conflict_answered.set()
+
     def _check_and_update(self):
         """Check for updates and perform installation"""
         self.progress_updated.emit(0, "正在检查更新...")
@@ -195,6 +221,11 @@ class UpdaterGUI(QWidget):
         self.progress_label = QLabel("就绪")
         progress_layout.addWidget(self.progress_label)
 
+        self.pause_button = QPushButton("暂停下载")
+        self.pause_button.clicked.connect(self.toggle_download_pause)
+        self.pause_button.hide()
+        progress_layout.addWidget(self.pause_button)
+
         layout.addWidget(progress_group)
         self.progress_group = progress_group
         self.progress_group.hide()
@@ -267,11 +298,25 @@ class UpdaterGUI(QWidget):
             QMessageBox.warning(self, "操作进行中", "请等待当前操作完成")
             return
 
+        # A manual download may override the size limit after confirmation
+        size_error = self.updater.check_asset_size(self.current_release_info.size or 0)
+        if size_error:
+            reply = QMessageBox.question(
+                self, "文件过大",
+                f"{size_error}。\n仍然下载吗？",
+                QMessageBox.Yes | QMessageBox.No
+            )
+            if reply != QMessageBox.Yes:
+                return
+
         self.progress_group.show()
         self.download_button.setEnabled(False)
+        self.pause_button.setText("暂停下载")
+        self.pause_button.show()
 
         self.update_worker = UpdateWorker(self.updater, "download_update")
         self.update_worker.set_release_info(self.current_release_info)
+        self.update_worker.ignore_size_limit = bool(size_error)
         self.update_worker.progress_updated.connect(self.on_progress_updated)
         self.update_worker.update_completed.connect(self.on_update_completed)
         self.update_worker.start()
@@ -300,10 +345,20 @@ class UpdaterGUI(QWidget):
 
         self.update_worker = UpdateWorker(self.updater, "install_update")
         self.update_worker.set_release_info(self.current_release_info)
+        self.update_worker.lock_conflict.connect(self.on_lock_conflict)
         self.update_worker.progress_updated.connect(self.on_progress_updated)
         self.update_worker.update_completed.connect(self.on_update_completed)
         self.update_worker.start()
 
+    def toggle_download_pause(self):
+        """Pause or resume the running download"""
+        if self.updater.is_download_paused():
+            self.updater.resume_download()
+            self.pause_button.setText("暂停下载")
+        else:
+            self.updater.pause_download()
+            self.pause_button.setText("继续下载")
+
     def start_zed(self):
         """Start Zed application"""
         zed_path = self.config.get('zed_install_path')
@@ -313,9 +368,9 @@ class UpdaterGUI(QWidget):
             return
 
         try:
-            success = self.updater.start_zed()
-            if success:
-                QMessageBox.information(self, "启动成功", "Zed已启动")
+            pid = self.updater.start_zed()
+            if pid:
+                QMessageBox.information(self, "启动成功", f"Zed已启动 (PID {pid})")
             else:
                 QMessageBox.critical(self, "启动失败", "无法启动Zed")
         except Exception as e:
@@ -348,9 +403,11 @@ class UpdaterGUI(QWidget):
                 self.toggle_scheduler_button.setText("停止定时任务")
 
                 if status.next_run_time:
-                    self.next_run_label.setText(
-                        status.next_run_time.strftime("%Y-%m-%d %H:%M:%S")
-                    )
+                    text = status.next_run_time.strftime("%Y-%m-%d %H:%M:%S")
+                    if (status.effective_interval_hours and
+                            status.effective_interval_hours > self.config.get('check_interval_hours', 24)):
+                        text += f" (间隔已延长到 {status.effective_interval_hours:g} 小时)"
+                    self.next_run_label.setText(text)
                 else:
                     self.next_run_label.setText("未设置")
             else:
@@ -368,6 +425,29 @@ class UpdaterGUI(QWidget):
         self.progress_label.setText(message)
         self.status_label.setText(message)
 
+    def on_lock_conflict(self, conflict: LockConflict):
+        """Let the user decide what to do with a locked Zed executable"""
+        if conflict.processes:
+            holders = "\n".join(f"- {p['name']} (PID {p['pid']})" for p in conflict.processes)
+        else:
+            holders = "- 未知进程"
+
+        box = QMessageBox(self)
+        box.setIcon(QMessageBox.Warning)
+        box.setWindowTitle("文件被占用")
+        box.setText(f"无法替换 {conflict.file_path}，以下进程正在使用它:\n{holders}")
+        retry_button = box.addButton("重试", QMessageBox.AcceptRole)
+        force_button = box.addButton("强制关闭", QMessageBox.DestructiveRole)
+        schedule_button = box.addButton("退出后安装", QMessageBox.ActionRole)
+        cancel_button = box.addButton("取消", QMessageBox.RejectRole)
+        box.setEscapeButton(cancel_button)
+        box.exec_()
+
+        choices = {retry_button: 'retry', force_button: 'force_close',
+                   schedule_button: 'schedule', cancel_button: 'cancel'}
+        if self.update_worker:
+            self.update_worker.answer_conflict(choices.get(box.clickedButton(), 'cancel'))
+
     def on_version_info_received(self, release_info: ReleaseInfo):
         """Handle version information received"""
         self.current_release_info = release_info
@@ -391,6 +471,7 @@ class UpdaterGUI(QWidget):
     def on_update_completed(self, success: bool, message: str):
         """Handle update operation completion"""
         self.progress_group.hide()
+        self.pause_button.hide()
         self.status_label.setText("完成" if success else "失败")
 
         # Re-enable buttons
@@ -430,6 +511,9 @@ class UpdaterGUI(QWidget):
     def closeEvent(self, event):
         """Handle widget close event"""
         if self.update_worker and self.update_worker.isRunning():
+            # Stops the download, a paused one would otherwise block the worker forever
+            self.updater.request_shutdown()
+            self.update_worker.answer_conflict('cancel')
             self.update_worker.quit()
             self.update_worker.wait()
         event.accept()
\ No newline at end of file
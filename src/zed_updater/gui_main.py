@@ -25,6 +25,7 @@ from PyQt5.QtGui import QFont
 
 from .core.config import ConfigManager
 from .core.updater import ZedUpdater
+from .core.diagnostics import StartupDiagnostics
 from .utils.logger import get_logger
 
 
@@ -116,6 +117,11 @@ class SimpleUpdaterGUI(QMainWindow):
     def load_settings(self):
         """Load settings and display current version"""
         self.log_message("正在加载设置...")
+
+        report = StartupDiagnostics(self.config, self.updater).run()
+        for check in report.checks:
+            if check.status != 'ok' or check.name == 'pending_install':
+                self.log_message(f"启动检查 {check.name}: {check.message}")
         
         # Get current version
         current_version = self.updater.get_current_version()
@@ -212,6 +218,11 @@ class SimpleUpdaterGUI(QMainWindow):
 
 def main():
     """Main GUI entry point"""
+    # The frozen build re-runs itself elevated with CLI arguments
+    if '--install-file' in sys.argv[1:]:
+        from .cli import main as cli_main
+        return cli_main()
+
     try:
         # Create Qt application
         app = QApplication(sys.argv)
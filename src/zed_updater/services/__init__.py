@@ -5,11 +5,25 @@ External services and API integrations for Zed Updater
 """
 
 from .github_api import GitHubAPI
+from .gitlab_api import GitLabAPI
+from .gitea_api import GiteaAPI
+from .manifest_source import ManifestSource
 from .system_service import SystemService
 from .notification_service import NotificationService
+from .task_scheduler import SystemTaskScheduler
+from .zed_config import ZedConfigBackup
+from .backup_mirror import BackupMirror
+from .download_mirror import DownloadAccelerator
 
 __all__ = [
     'GitHubAPI',
+    'GitLabAPI',
+    'GiteaAPI',
+    'ManifestSource',
     'SystemService',
-    'NotificationService'
+    'NotificationService',
+    'SystemTaskScheduler',
+    'ZedConfigBackup',
+    'BackupMirror',
+    'DownloadAccelerator'
 ]
\ No newline at end of file
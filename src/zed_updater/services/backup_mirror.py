@@ -0,0 +1,129 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Copy backups to a secondary destination
+"""
+
+import json
+import shutil
+import threading
+from datetime import datetime
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+from ..utils.logger import get_logger
+
+
+class BackupMirror:
+    """Mirror backup files to a network share, another drive or S3
+
+    The destination is either a directory (a UNC path such as
+    ``\\\\nas\\backups\\zed`` works like any other) or ``s3://bucket/prefix``.
+    S3 uploads need boto3; endpoint_url selects an S3-compatible service
+    such as MinIO, credentials come from the usual AWS environment
+    variables or config files.
+
+    Mirroring runs in a background thread. The thread is not a daemon, so
+    a short-lived CLI process still finishes its uploads before exiting.
+    The outcome of the last run is kept in status_file.
+    """
+
+    def __init__(self, destination: str, backup_root: Path, status_file: Path,
+                 endpoint_url: str = ""):
+        self.logger = get_logger(__name__)
+        self.destination = destination or ""
+        self.backup_root = Path(backup_root)
+        self.status_file = Path(status_file)
+        self.endpoint_url = endpoint_url or None
+        self._lock = threading.Lock()
+        self._threads: List[threading.Thread] = []
+
+    @property
+    def enabled(self) -> bool:
+        return bool(self.destination)
+
+    def mirror_async(self, files: List[Path]) -> None:
+        """Start copying files (relative to the backup root) to the destination"""
+        if not self.enabled:
+            return
+        thread = threading.Thread(target=self.mirror, args=(files,), name="backup-mirror")
+        self._threads.append(thread)
+        thread.start()
+
+    def wait(self, timeout: Optional[float] = None) -> None:
+        """Wait for running mirror jobs"""
+        for thread in list(self._threads):
+            thread.join(timeout)
+        self._threads = [thread for thread in self._threads if thread.is_alive()]
+
+    def mirror(self, files: List[Path]) -> bool:
+        """Copy files to the destination now, returns False on any failure"""
+        copied = []
+        error = None
+        with self._lock:
+            try:
+                if self.destination.startswith('s3://'):
+                    upload = self._s3_uploader()
+                else:
+                    upload = self._copy_to_directory
+                for path in files:
+                    if path.exists():
+                        upload(path, self._relative_name(path))
+                        copied.append(path.name)
+            except Exception as e:
+                error = str(e)
+                self.logger.error(f"备份同步到 {self.destination} 失败: {e}")
+
+            if copied and not error:
+                self.logger.info(f"已同步 {len(copied)} 个备份文件到 {self.destination}")
+            self._save_status({
+                'destination': self.destination,
+                'timestamp': datetime.now().isoformat(timespec='seconds'),
+                'success': error is None,
+                'files': copied,
+                'error': error,
+            })
+        return error is None
+
+    def get_status(self) -> Optional[Dict[str, Any]]:
+        """Outcome of the last mirror run, None if there was none"""
+        try:
+            with open(self.status_file, 'r', encoding='utf-8') as f:
+                return json.load(f)
+        except (OSError, ValueError):
+            return None
+
+    def _save_status(self, status: Dict[str, Any]) -> None:
+        try:
+            self.status_file.parent.mkdir(parents=True, exist_ok=True)
+            with open(self.status_file, 'w', encoding='utf-8') as f:
+                json.dump(status, f, indent=2, ensure_ascii=False)
+        except OSError as e:
+            self.logger.warning(f"保存备份同步状态失败: {e}")
+
+    def _relative_name(self, path: Path) -> str:
+        try:
+            return path.relative_to(self.backup_root).as_posix()
+        except ValueError:
+            return path.name
+
+    def _copy_to_directory(self, path: Path, name: str) -> None:
+        target = Path(self.destination) / name
+        target.parent.mkdir(parents=True, exist_ok=True)
+        tmp_target = target.with_name(target.name + '.tmp')
+        shutil.copy2(path, tmp_target)
+        tmp_target.replace(target)
+
+    def _s3_uploader(self):
+        try:
+            import boto3
+        except ImportError:
+            raise RuntimeError("同步到 S3 需要安装 boto3")
+
+        bucket, _, prefix = self.destination[len('s3://'):].partition('/')
+        client = boto3.client('s3', endpoint_url=self.endpoint_url)
+        prefix = prefix.strip('/')
+
+        def upload(path: Path, name: str) -> None:
+            client.upload_file(str(path), bucket, f"{prefix}/{name}" if prefix else name)
+        return upload
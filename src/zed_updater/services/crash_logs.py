@@ -0,0 +1,69 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Zed crash and panic log discovery
+"""
+
+import os
+import platform
+from datetime import datetime
+from pathlib import Path
+from typing import List, Tuple
+
+from ..utils.logger import get_logger
+
+
+class CrashLogCollector:
+    """Find crash reports and panic logs written by Zed"""
+
+    MAX_LOGS = 20
+    MAX_LOG_SIZE = 50 * 1024 * 1024  # Full memory dumps are not worth keeping
+
+    def __init__(self):
+        self.logger = get_logger(__name__)
+        self.system = platform.system()
+
+    def get_search_locations(self) -> List[Tuple[Path, str]]:
+        """Directories and glob patterns where Zed leaves crash evidence"""
+        home = Path.home()
+
+        if self.system == "Windows":
+            local = Path(os.environ.get('LOCALAPPDATA', home / 'AppData' / 'Local'))
+            return [
+                (local / 'Zed' / 'logs', '*.panic'),
+                (local / 'Zed' / 'crashes', '*.dmp'),
+                (local / 'CrashDumps', 'Zed*.dmp'),  # Windows Error Reporting
+            ]
+
+        if self.system == "Darwin":
+            return [
+                (home / 'Library' / 'Logs' / 'Zed', '*.panic'),
+                (home / 'Library' / 'Logs' / 'DiagnosticReports', 'Zed*.ips'),
+                (home / 'Library' / 'Logs' / 'DiagnosticReports', 'Zed*.crash'),
+            ]
+
+        data_home = Path(os.environ.get('XDG_DATA_HOME', home / '.local' / 'share'))
+        return [
+            (data_home / 'zed' / 'logs', '*.panic'),
+            (data_home / 'zed' / 'crashes', '*.dmp'),
+        ]
+
+    def find_crash_logs(self, since: datetime) -> List[Path]:
+        """Crash logs modified after since, oldest first"""
+        since_ts = since.timestamp()
+        found = []
+
+        for directory, pattern in self.get_search_locations():
+            if not directory.is_dir():
+                continue
+            try:
+                for path in directory.glob(pattern):
+                    stat = path.stat()
+                    if (path.is_file() and stat.st_mtime > since_ts and
+                            stat.st_size <= self.MAX_LOG_SIZE):
+                        found.append(path)
+            except OSError as e:
+                self.logger.debug(f"无法读取崩溃日志目录 {directory}: {e}")
+
+        found.sort(key=lambda path: path.stat().st_mtime)
+        return found[-self.MAX_LOGS:]
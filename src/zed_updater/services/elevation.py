@@ -0,0 +1,296 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Privilege elevation for installs into protected locations
+"""
+
+import os
+import sys
+import platform
+import subprocess
+import tempfile
+from pathlib import Path
+from typing import Callable, Dict, List, NamedTuple, Optional
+
+from ..core.exceptions import ElevationError
+from ..utils.logger import get_logger
+
+
+ERROR_CANCELLED = 1223  # The user declined the UAC prompt
+
+
+def is_admin() -> bool:
+    """Check if the current process runs with administrator rights"""
+    if platform.system() == "Windows":
+        try:
+            import ctypes
+            return bool(ctypes.windll.shell32.IsUserAnAdmin())
+        except (AttributeError, OSError):
+            return False
+    return hasattr(os, 'geteuid') and os.geteuid() == 0
+
+
+def can_write_to(directory: Path) -> bool:
+    """Check if files can be created in a directory
+
+    os.access is unreliable on Windows (it ignores ACLs and UAC
+    virtualisation), so a real file is created and removed.
+    """
+    directory = Path(directory)
+    while not directory.exists() and directory.parent != directory:
+        directory = directory.parent
+    try:
+        fd, probe = tempfile.mkstemp(prefix='.zed_updater_probe_', dir=directory)
+        os.close(fd)
+        os.unlink(probe)
+        return True
+    except OSError:
+        return False
+
+
+class DesktopUser(NamedTuple):
+    """Account that started an elevated updater through sudo or pkexec"""
+    uid: int
+    gid: int
+    name: str
+    home: str
+
+
+def desktop_user() -> Optional[DesktopUser]:
+    """The user behind sudo or pkexec when running as root on POSIX
+
+    None when not elevated, when root logged in directly, or on Windows,
+    where ElevationHelper.start_as_desktop_user is used instead.
+    """
+    if platform.system() == "Windows" or not is_admin():
+        return None
+    import pwd
+    uid = os.environ.get('SUDO_UID') or os.environ.get('PKEXEC_UID')
+    try:
+        entry = pwd.getpwuid(int(uid))
+    except (TypeError, ValueError, KeyError):
+        return None
+    if entry.pw_uid == 0:
+        return None
+    return DesktopUser(entry.pw_uid, entry.pw_gid, entry.pw_name, entry.pw_dir)
+
+
+def drop_privileges(user: DesktopUser) -> Callable[[], None]:
+    """preexec_fn for subprocess.Popen that switches the child to user"""
+    def switch():
+        os.setgid(user.gid)
+        os.setgroups(os.getgrouplist(user.name, user.gid))
+        os.setuid(user.uid)
+    return switch
+
+
+def self_command() -> List[str]:
+    """Command line that re-runs this program's CLI"""
+    if getattr(sys, 'frozen', False):
+        return [sys.executable]
+    return [sys.executable, '-m', 'zed_updater.cli']
+
+
+class ElevationHelper:
+    """Re-run an updater command with administrator rights"""
+
+    def __init__(self):
+        self.logger = get_logger(__name__)
+        self.is_windows = platform.system() == "Windows"
+
+    def run_elevated(self, args: List[str], timeout: float = 600) -> int:
+        """Run this program's CLI elevated and wait for it
+
+        Args:
+            args: CLI arguments for the elevated process
+            timeout: Seconds to wait for the elevated process
+
+        Returns:
+            Exit code of the elevated process
+
+        Raises:
+            ElevationError: If elevation is unsupported or was declined
+        """
+        command = self_command() + list(args)
+
+        if not self.is_windows:
+            raise ElevationError(
+                "安装目录需要管理员权限，请使用 sudo 运行或选择可写的安装路径"
+            )
+
+        import ctypes
+        from ctypes import wintypes
+
+        class SHELLEXECUTEINFOW(ctypes.Structure):
+            _fields_ = [
+                ('cbSize', wintypes.DWORD),
+                ('fMask', ctypes.c_ulong),
+                ('hwnd', wintypes.HWND),
+                ('lpVerb', wintypes.LPCWSTR),
+                ('lpFile', wintypes.LPCWSTR),
+                ('lpParameters', wintypes.LPCWSTR),
+                ('lpDirectory', wintypes.LPCWSTR),
+                ('nShow', ctypes.c_int),
+                ('hInstApp', wintypes.HINSTANCE),
+                ('lpIDList', ctypes.c_void_p),
+                ('lpClass', wintypes.LPCWSTR),
+                ('hkeyClass', wintypes.HKEY),
+                ('dwHotKey', wintypes.DWORD),
+                ('hIcon', wintypes.HANDLE),
+                ('hProcess', wintypes.HANDLE),
+            ]
+
+        SEE_MASK_NOCLOSEPROCESS = 0x00000040
+        SW_HIDE = 0
+        INFINITE = 0xFFFFFFFF
+
+        info = SHELLEXECUTEINFOW()
+        info.cbSize = ctypes.sizeof(info)
+        info.fMask = SEE_MASK_NOCLOSEPROCESS
+        info.lpVerb = "runas"
+        info.lpFile = command[0]
+        info.lpParameters = subprocess.list2cmdline(command[1:])
+        info.nShow = SW_HIDE
+
+        self.logger.info(f"请求管理员权限运行: {subprocess.list2cmdline(command)}")
+        if not ctypes.windll.shell32.ShellExecuteExW(ctypes.byref(info)):
+            error = ctypes.windll.kernel32.GetLastError()
+            if error == ERROR_CANCELLED:
+                raise ElevationError("用户拒绝了管理员权限请求，无法安装到受保护的目录", declined=True)
+            raise ElevationError(f"无法以管理员身份启动安装程序 (错误 {error})")
+
+        kernel32 = ctypes.windll.kernel32
+        try:
+            wait_ms = int(timeout * 1000) if timeout else INFINITE
+            kernel32.WaitForSingleObject(info.hProcess, wait_ms)
+            exit_code = wintypes.DWORD()
+            kernel32.GetExitCodeProcess(info.hProcess, ctypes.byref(exit_code))
+            return exit_code.value
+        finally:
+            kernel32.CloseHandle(info.hProcess)
+
+    def start_as_desktop_user(self, command: List[str], cwd: Optional[str] = None,
+                              extra_env: Optional[Dict[str, str]] = None) -> int:
+        """Start a program as the desktop user from an elevated process (Windows)
+
+        Uses the token of the shell (explorer.exe), so the program runs
+        unelevated as whoever is logged in, even if the updater was
+        elevated with another administrator's account. The environment is
+        that user's, plus extra_env.
+
+        Returns:
+            PID of the started program
+
+        Raises:
+            ElevationError: If there is no shell or its token can't be used
+        """
+        import ctypes
+        from ctypes import wintypes
+
+        class STARTUPINFOW(ctypes.Structure):
+            _fields_ = [
+                ('cb', wintypes.DWORD),
+                ('lpReserved', wintypes.LPWSTR),
+                ('lpDesktop', wintypes.LPWSTR),
+                ('lpTitle', wintypes.LPWSTR),
+                ('dwX', wintypes.DWORD),
+                ('dwY', wintypes.DWORD),
+                ('dwXSize', wintypes.DWORD),
+                ('dwYSize', wintypes.DWORD),
+                ('dwXCountChars', wintypes.DWORD),
+                ('dwYCountChars', wintypes.DWORD),
+                ('dwFillAttribute', wintypes.DWORD),
+                ('dwFlags', wintypes.DWORD),
+                ('wShowWindow', wintypes.WORD),
+                ('cbReserved2', wintypes.WORD),
+                ('lpReserved2', ctypes.c_void_p),
+                ('hStdInput', wintypes.HANDLE),
+                ('hStdOutput', wintypes.HANDLE),
+                ('hStdError', wintypes.HANDLE),
+            ]
+
+        class PROCESS_INFORMATION(ctypes.Structure):
+            _fields_ = [
+                ('hProcess', wintypes.HANDLE),
+                ('hThread', wintypes.HANDLE),
+                ('dwProcessId', wintypes.DWORD),
+                ('dwThreadId', wintypes.DWORD),
+            ]
+
+        PROCESS_QUERY_INFORMATION = 0x0400
+        TOKEN_ASSIGN_PRIMARY = 0x0001
+        TOKEN_DUPLICATE = 0x0002
+        TOKEN_QUERY = 0x0008
+        TOKEN_ADJUST_DEFAULT = 0x0080
+        TOKEN_ADJUST_SESSIONID = 0x0100
+        SECURITY_IMPERSONATION = 2
+        TOKEN_PRIMARY = 1
+        DETACHED_PROCESS = 0x00000008
+        CREATE_NEW_PROCESS_GROUP = 0x00000200
+        CREATE_UNICODE_ENVIRONMENT = 0x00000400
+
+        user32 = ctypes.windll.user32
+        kernel32 = ctypes.windll.kernel32
+        advapi32 = ctypes.windll.advapi32
+        userenv = ctypes.windll.userenv
+
+        shell_window = user32.GetShellWindow()
+        if not shell_window:
+            raise ElevationError("找不到桌面 Shell，无法以普通用户身份启动")
+        shell_pid = wintypes.DWORD()
+        user32.GetWindowThreadProcessId(shell_window, ctypes.byref(shell_pid))
+
+        shell_process = kernel32.OpenProcess(PROCESS_QUERY_INFORMATION, False, shell_pid)
+        if not shell_process:
+            raise ElevationError(f"无法打开桌面 Shell 进程 (错误 {kernel32.GetLastError()})")
+        shell_token = wintypes.HANDLE()
+        token = wintypes.HANDLE()
+        environment = ctypes.c_void_p()
+        try:
+            if not advapi32.OpenProcessToken(shell_process, TOKEN_DUPLICATE, ctypes.byref(shell_token)):
+                raise ElevationError(f"无法读取桌面用户的令牌 (错误 {kernel32.GetLastError()})")
+            access = (TOKEN_QUERY | TOKEN_DUPLICATE | TOKEN_ASSIGN_PRIMARY |
+                      TOKEN_ADJUST_DEFAULT | TOKEN_ADJUST_SESSIONID)
+            if not advapi32.DuplicateTokenEx(shell_token, access, None, SECURITY_IMPERSONATION,
+                                             TOKEN_PRIMARY, ctypes.byref(token)):
+                raise ElevationError(f"无法复制桌面用户的令牌 (错误 {kernel32.GetLastError()})")
+
+            variables: Dict[str, str] = {}
+            if userenv.CreateEnvironmentBlock(ctypes.byref(environment), token, False):
+                # Block of NUL terminated KEY=VALUE strings, ending with an empty one
+                offset = 0
+                while True:
+                    entry = ctypes.wstring_at(environment.value + offset)
+                    if not entry:
+                        break
+                    key, _, value = entry.partition('=')
+                    variables[key] = value
+                    offset += (len(entry) + 1) * ctypes.sizeof(ctypes.c_wchar)
+            for key, value in (extra_env or {}).items():
+                # Names are case-insensitive on Windows
+                for existing in [name for name in variables if name.upper() == key.upper()]:
+                    del variables[existing]
+                variables[key] = value
+            block = ctypes.create_unicode_buffer(
+                ''.join(f"{key}={value}\0" for key, value in variables.items()) + '\0')
+
+            startup = STARTUPINFOW()
+            startup.cb = ctypes.sizeof(startup)
+            process_info = PROCESS_INFORMATION()
+            command_line = ctypes.create_unicode_buffer(subprocess.list2cmdline(command))
+            self.logger.info(f"以桌面用户身份启动: {command_line.value}")
+            if not advapi32.CreateProcessWithTokenW(
+                    token, 0, None, command_line,
+                    DETACHED_PROCESS | CREATE_NEW_PROCESS_GROUP | CREATE_UNICODE_ENVIRONMENT,
+                    block, cwd, ctypes.byref(startup), ctypes.byref(process_info)):
+                raise ElevationError(f"无法以桌面用户身份启动 (错误 {kernel32.GetLastError()})")
+            kernel32.CloseHandle(process_info.hThread)
+            kernel32.CloseHandle(process_info.hProcess)
+            return process_info.dwProcessId
+        finally:
+            if environment.value:
+                userenv.DestroyEnvironmentBlock(environment)
+            for handle in (token, shell_token):
+                if handle.value:
+                    kernel32.CloseHandle(handle)
+            kernel32.CloseHandle(shell_process)
@@ -0,0 +1,50 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Gitea / Forgejo release source for Zed Updater
+"""
+
+from typing import Dict, Any, Optional
+from urllib.parse import quote, urlparse
+
+from .github_api import GitHubAPI
+
+
+class GiteaAPI(GitHubAPI):
+    """Fetch Zed builds from the releases of a Gitea or Forgejo repository
+
+    Both serve the same API under /api/v1. Its release objects already
+    look like GitHub's, only paging and the token header differ.
+    """
+
+    SOURCE_NAME = "Gitea"
+
+    def __init__(self, repo: str, base_url: str, token: Optional[str] = None):
+        base_url = base_url.rstrip('/')
+        super().__init__(repo, api_url=f"{base_url}/api/v1", token=token)
+        self.host = urlparse(base_url).netloc
+
+    def _auth_headers(self) -> Dict[str, str]:
+        return {'Authorization': f"token {self.token}"} if self.token else {}
+
+    def _release_by_tag_endpoint(self, tag: str) -> str:
+        return f"/repos/{self.repo}/releases/tags/{quote(tag, safe='')}"
+
+    def _page_params(self, per_page: int, page: int = 1) -> Dict[str, Any]:
+        return {'limit': per_page, 'page': page}
+
+    def _rate_limit_endpoint(self) -> Optional[str]:
+        return None
+
+    def _normalize_release(self, data: Dict[str, Any]) -> Dict[str, Any]:
+        data = dict(data)
+        assets = []
+        for asset in data.get('assets') or []:
+            asset = dict(asset)
+            url = asset.get('browser_download_url', '')
+            # Attachments of private repositories need the token, nothing else gets it
+            asset['url'] = url if urlparse(url).netloc == self.host else ''
+            assets.append(asset)
+        data['assets'] = assets
+        data['published_at'] = data.get('published_at') or data.get('created_at')
+        return data
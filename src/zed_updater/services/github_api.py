@@ -4,15 +4,39 @@
 GitHub API service for Zed Updater
 """
 
+import re
+import json
 import time
+import platform
+import fnmatch
 import hashlib
-from typing import Dict, Any, Optional, List
+from pathlib import Path
+from typing import Dict, Any, Optional, List, Tuple
 from dataclasses import dataclass
 from datetime import datetime
+from urllib.parse import urlencode, urlparse
 
 import requests
 
+from ..core.exceptions import RateLimitError
 from ..utils.logger import get_logger
+from ..utils.archive import ARCHIVE_SUFFIXES
+
+
+class ScopedAuthSession(requests.Session):
+    """Session that drops every token header on redirects to another host
+
+    requests only strips Authorization, while GitLab's token travels in
+    PRIVATE-TOKEN and asset links may redirect to object storage.
+    """
+
+    TOKEN_HEADERS = ('Authorization', 'PRIVATE-TOKEN')
+
+    def rebuild_auth(self, prepared_request, response):
+        super().rebuild_auth(prepared_request, response)
+        if self.should_strip_auth(response.request.url, prepared_request.url):
+            for header in self.TOKEN_HEADERS:
+                prepared_request.headers.pop(header, None)
 
 
 @dataclass
@@ -22,6 +46,8 @@ class ReleaseAsset:
     download_url: str
     size: int
     content_type: str
+    api_url: str = ""  # API endpoint, works for private repositories
+    sha256: Optional[str] = None  # From GitHub's "digest" field, missing on older releases
 
 
 @dataclass
@@ -34,44 +60,123 @@ class ReleaseInfo:
     size: int
     sha256: Optional[str]
     assets: List[ReleaseAsset]
+    asset_api_url: str = ""
+    cached: bool = False  # GitHub answered 304, the release is unchanged since the last check
+    prerelease: bool = False
+    source: str = ""  # Release source it came from, see GitHubAPI.describe
 
 
 class GitHubAPI:
-    """GitHub API client for fetching Zed releases"""
+    """GitHub API client for fetching Zed releases
+
+    Also the base of the other release sources (see gitlab_api). They
+    override the endpoint and authentication hooks and translate their
+    release objects into GitHub's shape in _normalize_release, so release
+    filtering and asset selection work the same for every source.
+    """
+
+    SOURCE_NAME = "GitHub"
 
     API_BASE = "https://api.github.com"
     REQUEST_TIMEOUT = 30
     MAX_RETRIES = 3
     RETRY_DELAY = 2
-
-    def __init__(self, repo: str = "TC999/zed-loc", api_url: Optional[str] = None):
+    DEFAULT_RETRY_AFTER = 60
+    MAX_RETRY_AFTER = 10  # Longer waits are surfaced to the caller instead of sleeping
+    RATE_LIMIT_RESERVE = 5  # Stop issuing requests this close to the limit
+    NIGHTLY_TAG = re.compile(r'(^|[-_.])nightly([-_.]|$)', re.IGNORECASE)
+    PLATFORM_KEYWORDS = {
+        'windows': ('windows', 'win64', 'win32', 'win'),
+        'linux': ('linux',),
+        'macos': ('macos', 'darwin', 'osx', 'mac'),
+    }
+    PLATFORM_SUFFIXES = {
+        'windows': ('.exe', '.msi'),
+        'linux': ('.tar.gz', '.tgz'),
+    }
+    # What ZedUpdater can install; .dmg, .tar.xz, .AppImage etc. are never picked
+    INSTALLABLE_SUFFIXES = ('.exe', '.msi') + ARCHIVE_SUFFIXES
+    ARCH_KEYWORDS = {
+        'x86_64': ('x86_64', 'x86-64', 'x64', 'amd64'),
+        'aarch64': ('aarch64', 'arm64'),
+    }
+    DELTA_SUFFIXES = ('.patch', '.bsdiff')
+    CHECKSUM_SUFFIX = '.sha256'
+
+    def __init__(self, repo: str = "TC999/zed-loc", api_url: Optional[str] = None,
+                 token: Optional[str] = None):
         self.logger = get_logger(__name__)
         self.repo = repo
         self.api_base = api_url or self.API_BASE
-        self.session = requests.Session()
+        self.token = token or ""
+        # The token is added per request, see _auth_headers_for
+        self.session = ScopedAuthSession()
         self.session.headers.update({
             'User-Agent': 'ZedUpdater/2.1.0',
             'Accept': 'application/vnd.github.v3+json'
         })
+        self._rate_limited_until = 0.0
+        self.rate_limit: Dict[str, int] = {}
+        self.cache_file: Optional[Path] = None
+        self._etag_cache: Dict[str, Dict[str, Any]] = {}
+        self.last_response_cached = False
+        self.tag_pattern = ""
+        self.ignored_tags: List[str] = []
+        self.ignore_drafts = True
+        self.channel = "stable"
+        self.languages: List[str] = []
+        self.platform, self.arch = self.detect_platform()
+        self.asset_pattern: Optional[re.Pattern] = None
 
     def _make_request(self, endpoint: str, params: Optional[Dict[str, Any]] = None) -> Optional[Dict[str, Any]]:
         """Make API request with retry logic"""
         url = f"{self.api_base}{endpoint}"
+        cache_key = f"{url}?{urlencode(sorted(params.items()))}" if params else url
+        cached = self._etag_cache.get(cache_key)
+        self.last_response_cached = False
+
+        if self.is_rate_limited():
+            wait = self._rate_limited_until - time.time()
+            raise RateLimitError(
+                f"GitHub API rate limited, retry in {wait:.0f}s",
+                retry_after=wait
+            )
 
         for attempt in range(self.MAX_RETRIES):
             try:
-                response = self.session.get(url, params=params, timeout=self.REQUEST_TIMEOUT)
-
-                if response.status_code == 200:
-                    return response.json()
+                headers = self._auth_headers_for(url)
+                if cached:
+                    headers['If-None-Match'] = cached['etag']
+                response = self.session.get(url, params=params, timeout=self.REQUEST_TIMEOUT, headers=headers)
+                self._update_rate_limit(response)
+
+                if response.status_code == 304 and cached:
+                    self.logger.debug(f"Not modified, using cached response: {url}")
+                    self.last_response_cached = True
+                    return self._normalize_response(cached['data'])
+                elif response.status_code == 200:
+                    data = response.json()
+                    etag = response.headers.get('ETag')
+                    if etag and self.cache_file:
+                        self._etag_cache[cache_key] = {'etag': etag, 'data': data}
+                        self._save_etag_cache()
+                    return self._normalize_response(data)
                 elif response.status_code == 404:
                     self.logger.warning(f"Resource not found: {url}")
                     return None
-                elif response.status_code == 403:
-                    self.logger.warning(f"Rate limited or forbidden: {url}")
-                    if attempt < self.MAX_RETRIES - 1:
-                        time.sleep(self.RETRY_DELAY * (attempt + 1))
-                        continue
+                elif response.status_code in (403, 429):
+                    rate_limit = self._detect_rate_limit(response)
+                    if rate_limit:
+                        self._rate_limited_until = time.time() + rate_limit.retry_after
+                        self.logger.warning(f"{rate_limit} ({url})")
+                        if (attempt < self.MAX_RETRIES - 1 and
+                                rate_limit.retry_after <= self.MAX_RETRY_AFTER):
+                            time.sleep(rate_limit.retry_after)
+                            self._rate_limited_until = 0.0
+                            continue
+                        raise rate_limit
+
+                    self.logger.warning(f"Forbidden: {url}")
                     return None
                 else:
                     self.logger.error(f"API request failed: {response.status_code} - {url}")
@@ -88,151 +193,299 @@ class GitHubAPI:
 
         return None
 
+    def _detect_rate_limit(self, response: requests.Response) -> Optional[RateLimitError]:
+        """Translate a 403/429 response into a RateLimitError if it is one
+
+        GitHub signals primary limits with X-RateLimit-Remaining: 0 and
+        secondary (abuse detection) limits with Retry-After and/or a
+        message mentioning "secondary rate limit" or "abuse".
+        """
+        headers = response.headers
+        try:
+            data = response.json() or {}
+        except ValueError:
+            data = {}
+        message = str(data.get('message', '')) if isinstance(data, dict) else ''
+
+        retry_after = headers.get('Retry-After')
+        if retry_after is not None:
+            try:
+                retry_after = float(retry_after)
+            except ValueError:
+                retry_after = None
+
+        secondary = ('secondary rate limit' in message.lower() or
+                     'abuse' in message.lower())
+
+        if headers.get('X-RateLimit-Remaining') == '0' and not secondary:
+            if retry_after is None:
+                try:
+                    retry_after = max(float(headers.get('X-RateLimit-Reset', 0)) - time.time(), 0)
+                except ValueError:
+                    retry_after = None
+            return RateLimitError(
+                f"GitHub API rate limit exceeded, resets in {retry_after or 0:.0f}s",
+                retry_after=retry_after or self.DEFAULT_RETRY_AFTER,
+                status_code=response.status_code,
+                response_data=data if isinstance(data, dict) else None
+            )
+
+        if secondary or retry_after is not None or response.status_code == 429:
+            # GitHub asks clients to wait at least a minute when no Retry-After is given
+            return RateLimitError(
+                f"GitHub API secondary rate limit hit: {message or response.status_code}",
+                retry_after=retry_after if retry_after is not None else self.DEFAULT_RETRY_AFTER,
+                secondary=True,
+                status_code=response.status_code,
+                response_data=data if isinstance(data, dict) else None
+            )
+
+        return None
+
+    @staticmethod
+    def enterprise_api_url(base_url: str) -> Optional[str]:
+        """API root of a GitHub Enterprise Server, None for github.com
+
+        Enterprise serves the REST API under /api/v3 of the instance
+        (https://github.example.com/api/v3) instead of a separate host.
+        Either the instance URL or the API root may be given.
+        """
+        base_url = (base_url or "").rstrip('/')
+        if not base_url or urlparse(base_url).netloc in ('github.com', 'api.github.com'):
+            return None
+        if base_url.endswith('/api/v3'):
+            return base_url
+        return f"{base_url}/api/v3"
+
+    def describe(self) -> str:
+        """Name of this source for logs and history, e.g. GitHub:TC999/zed-loc"""
+        return f"{self.SOURCE_NAME}:{self.repo}"
+
+    def _auth_headers(self) -> Dict[str, str]:
+        """Headers that authenticate API and asset requests with the token"""
+        return {'Authorization': f"Bearer {self.token}"} if self.token else {}
+
+    def _auth_headers_for(self, url: str) -> Dict[str, str]:
+        """Token headers for url, none for hosts other than the API's own
+
+        Release links and checksum files may live anywhere, the token
+        must not go with them.
+        """
+        if urlparse(url).netloc != urlparse(self.api_base).netloc:
+            return {}
+        return self._auth_headers()
+
+    def _latest_release_endpoint(self) -> str:
+        return f"/repos/{self.repo}/releases/latest"
+
+    def _releases_endpoint(self) -> str:
+        return f"/repos/{self.repo}/releases"
+
+    def _release_by_tag_endpoint(self, tag: str) -> str:
+        return f"/repos/{self.repo}/releases/tags/{tag}"
+
+    def _page_params(self, per_page: int, page: int = 1) -> Dict[str, Any]:
+        return {'per_page': per_page, 'page': page}
+
+    def _rate_limit_endpoint(self) -> Optional[str]:
+        """Endpoint reporting the rate limit, None if the source has none"""
+        return "/rate_limit"
+
+    def _normalize_release(self, data: Dict[str, Any]) -> Dict[str, Any]:
+        """Translate a release object of this source into GitHub's shape"""
+        return data
+
+    def _normalize_response(self, data: Any) -> Any:
+        if isinstance(data, list):
+            return [self._normalize_release(item) for item in data if isinstance(item, dict)]
+        if isinstance(data, dict):
+            return self._normalize_release(data)
+        return data
+
+    def set_cache_file(self, cache_file: Path) -> None:
+        """Keep responses with their ETag in cache_file for conditional requests
+
+        Later requests send If-None-Match; GitHub answers an unchanged
+        resource with 304, which does not count against the rate limit.
+        """
+        self.cache_file = Path(cache_file)
+        try:
+            with open(self.cache_file, 'r', encoding='utf-8') as f:
+                data = json.load(f)
+            self._etag_cache = data if isinstance(data, dict) else {}
+        except (OSError, ValueError):
+            self._etag_cache = {}
+
+    def _save_etag_cache(self) -> None:
+        try:
+            # Other sources share the file, keep their entries
+            with open(self.cache_file, 'r', encoding='utf-8') as f:
+                stored = json.load(f)
+            if isinstance(stored, dict):
+                self._etag_cache = dict(stored, **self._etag_cache)
+        except (OSError, ValueError):
+            pass
+        try:
+            self.cache_file.parent.mkdir(parents=True, exist_ok=True)
+            tmp_file = self.cache_file.with_suffix('.tmp')
+            with open(tmp_file, 'w', encoding='utf-8') as f:
+                json.dump(self._etag_cache, f, ensure_ascii=False)
+            tmp_file.replace(self.cache_file)
+        except OSError as e:
+            self.logger.warning(f"Failed to save release cache: {e}")
+
+    def _update_rate_limit(self, response: requests.Response) -> None:
+        """Track the X-RateLimit-* headers GitHub sends with every response
+
+        Unauthenticated clients get 60 requests an hour per IP. Once fewer
+        than RATE_LIMIT_RESERVE are left, requests are held back until the
+        window resets rather than running into 403s.
+        """
+        values = {}
+        for key in ('limit', 'remaining', 'reset', 'used'):
+            try:
+                values[key] = int(response.headers[f'X-RateLimit-{key.capitalize()}'])
+            except (KeyError, ValueError):
+                continue
+        if 'remaining' not in values:
+            return
+        self.rate_limit = values
+
+        remaining = values['remaining']
+        reset = values.get('reset', 0)
+        if 0 < remaining <= self.RATE_LIMIT_RESERVE and reset > time.time():
+            self._rate_limited_until = float(reset)
+            hint = "" if self.token else "，配置 github_token 可提高到每小时 5000 次"
+            self.logger.warning(
+                f"GitHub API 仅剩 {remaining}/{values.get('limit', '?')} 次请求，"
+                f"暂停到 {datetime.fromtimestamp(reset):%H:%M:%S}{hint}"
+            )
+
+    def get_rate_limit_status(self) -> Dict[str, Any]:
+        """Rate limit state as of the last response
+
+        Returns:
+            limit, remaining, used and reset (datetime) when GitHub reported
+            them, plus authenticated and paused_until (datetime or None).
+        """
+        status: Dict[str, Any] = {
+            key: self.rate_limit[key] for key in ('limit', 'remaining', 'used') if key in self.rate_limit
+        }
+        if 'reset' in self.rate_limit:
+            status['reset'] = datetime.fromtimestamp(self.rate_limit['reset'])
+        status['authenticated'] = bool(self.token)
+        status['paused_until'] = (datetime.fromtimestamp(self._rate_limited_until)
+                                  if self.is_rate_limited() else None)
+        return status
+
+    def fetch_rate_limit(self) -> Dict[str, Any]:
+        """Ask the source for the current rate limit, see get_rate_limit_status
+
+        GitHub's /rate_limit does not count against the limit itself. For
+        sources without such an endpoint, or when the request fails, the
+        state of the last response is returned.
+        """
+        endpoint = self._rate_limit_endpoint()
+        if endpoint:
+            try:
+                url = f"{self.api_base}{endpoint}"
+                response = self.session.get(url, headers=self._auth_headers_for(url), timeout=self.REQUEST_TIMEOUT)
+                self._update_rate_limit(response)
+            except requests.exceptions.RequestException as e:
+                self.logger.warning(f"Failed to fetch rate limit: {e}")
+        return self.get_rate_limit_status()
+
+    def is_rate_limited(self) -> bool:
+        """Check if a previous response put us in a rate-limited state"""
+        return time.time() < self._rate_limited_until
+
     def get_latest_release(self) -> Optional[ReleaseInfo]:
-        """Get latest release information"""
-        endpoint = f"/repos/{self.repo}/releases/latest"
-        data = self._make_request(endpoint)
+        """Get latest release information
+
+        /releases/latest knows nothing about tag filters or channels, so
+        with a tag pattern, ignored tags or a channel other than stable the
+        newest matching release is picked from the release list instead.
+        """
+        if self.tag_pattern or self.ignored_tags or self.channel != "stable":
+            return self._get_latest_filtered_release()
+
+        data = self._make_request(self._latest_release_endpoint())
 
         if not data:
             return None
 
         try:
-            # Parse release date
-            release_date = datetime.fromisoformat(data['published_at'].replace('Z', '+00:00'))
-
-            # Find Windows executable asset
-            download_url = ""
-            asset_size = 0
-            assets = []
-
-            for asset_data in data.get('assets', []):
-                asset = ReleaseAsset(
-                    name=asset_data['name'],
-                    download_url=asset_data['browser_download_url'],
-                    size=asset_data['size'],
-                    content_type=asset_data.get('content_type', '')
-                )
-                assets.append(asset)
-
-                # Prefer Windows executables
-                if self._is_windows_executable(asset.name):
-                    download_url = asset.download_url
-                    asset_size = asset.size
-
-            # Fallback to first asset if no Windows-specific found
-            if not download_url and assets:
-                download_url = assets[0].download_url
-                asset_size = assets[0].size
-
-            if not download_url:
+            release_info = self._parse_release(data)
+            release_info.cached = self.last_response_cached
+
+            if not release_info.download_url:
                 self.logger.error("No suitable download asset found")
                 return None
 
-            # Extract version from tag
-            tag_name = data.get('tag_name', '')
-            version = tag_name.lstrip('v') if tag_name else 'latest'
-
-            release_info = ReleaseInfo(
-                version=version,
-                release_date=release_date,
-                download_url=download_url,
-                description=data.get('body', ''),
-                size=asset_size,
-                sha256=None,  # GitHub doesn't provide SHA256 in API
-                assets=assets
-            )
-
-            self.logger.info(f"Retrieved latest release: {version}")
+            self.logger.info(f"Retrieved latest release: {release_info.version}"
+                             + (" (unchanged)" if release_info.cached else ""))
             return release_info
 
         except (KeyError, ValueError) as e:
             self.logger.error(f"Failed to parse release data: {e}")
             return None
 
+    def _get_latest_filtered_release(self) -> Optional[ReleaseInfo]:
+        """Newest release of the channel passing the release filter"""
+        data = self._make_request(self._releases_endpoint(), self._page_params(30))
+        cached = self.last_response_cached
+
+        for release_data in data or []:
+            if not self.matches_channel(release_data) or not self.is_release_allowed(release_data):
+                continue
+            try:
+                release_info = self._parse_release(release_data)
+            except (KeyError, ValueError) as e:
+                self.logger.warning(f"Failed to parse release data: {e}")
+                continue
+            if release_info.download_url:
+                release_info.cached = cached
+                self.logger.info(f"Retrieved latest matching release: {release_info.version}")
+                return release_info
+
+        self.logger.error(f"No {self.channel} release matches tag filter '{self.tag_pattern}'")
+        return None
+
     def get_release_by_tag(self, tag: str) -> Optional[ReleaseInfo]:
         """Get specific release by tag"""
-        endpoint = f"/repos/{self.repo}/releases/tags/{tag}"
-        data = self._make_request(endpoint)
+        data = self._make_request(self._release_by_tag_endpoint(tag))
 
         if not data:
             return None
 
-        # Similar parsing as get_latest_release
         try:
-            release_date = datetime.fromisoformat(data['published_at'].replace('Z', '+00:00'))
-
-            download_url = ""
-            asset_size = 0
-            assets = []
-
-            for asset_data in data.get('assets', []):
-                asset = ReleaseAsset(
-                    name=asset_data['name'],
-                    download_url=asset_data['browser_download_url'],
-                    size=asset_data['size'],
-                    content_type=asset_data.get('content_type', '')
-                )
-                assets.append(asset)
-
-                if self._is_windows_executable(asset.name):
-                    download_url = asset.download_url
-                    asset_size = asset.size
-
-            if not download_url and assets:
-                download_url = assets[0].download_url
-                asset_size = assets[0].size
-
-            version = tag.lstrip('v') if tag else tag
-
-            return ReleaseInfo(
-                version=version,
-                release_date=release_date,
-                download_url=download_url,
-                description=data.get('body', ''),
-                size=asset_size,
-                sha256=None,
-                assets=assets
-            )
+            release_info = self._parse_release(data)
+            release_info.cached = self.last_response_cached
+            return release_info
 
         except (KeyError, ValueError) as e:
             self.logger.error(f"Failed to parse release data for tag {tag}: {e}")
             return None
 
-    def get_releases(self, count: int = 10) -> List[ReleaseInfo]:
-        """Get list of recent releases"""
-        endpoint = f"/repos/{self.repo}/releases"
-        params = {'per_page': min(count, 100)}  # GitHub API limit
-        data = self._make_request(endpoint, params)
+    def get_releases(self, count: int = 10, page: int = 1) -> List[ReleaseInfo]:
+        """Get one page of releases, newest first
+
+        Args:
+            count: Releases per page, at most 100
+            page: 1-based page number
+        """
+        params = self._page_params(min(count, 100), max(page, 1))  # GitHub API limit
+        data = self._make_request(self._releases_endpoint(), params)
 
         if not data:
             return []
 
         releases = []
         for release_data in data:
+            if not self.is_release_allowed(release_data):
+                continue
             try:
-                release_date = datetime.fromisoformat(release_data['published_at'].replace('Z', '+00:00'))
-
-                assets = []
-                for asset_data in release_data.get('assets', []):
-                    asset = ReleaseAsset(
-                        name=asset_data['name'],
-                        download_url=asset_data['browser_download_url'],
-                        size=asset_data['size'],
-                        content_type=asset_data.get('content_type', '')
-                    )
-                    assets.append(asset)
-
-                release_info = ReleaseInfo(
-                    version=release_data.get('tag_name', '').lstrip('v'),
-                    release_date=release_date,
-                    download_url="",  # Will be set by caller if needed
-                    description=release_data.get('body', ''),
-                    size=0,  # Will be set by caller if needed
-                    sha256=None,
-                    assets=assets
-                )
-
-                releases.append(release_info)
+                releases.append(self._parse_release(release_data))
 
             except (KeyError, ValueError) as e:
                 self.logger.warning(f"Failed to parse release data: {e}")
@@ -240,12 +493,261 @@ class GitHubAPI:
 
         return releases
 
-    def _is_windows_executable(self, filename: str) -> bool:
-        """Check if filename indicates a Windows executable"""
+    def set_release_filter(self, tag_pattern: str = "", ignored_tags: Optional[List[str]] = None,
+                           ignore_drafts: bool = True) -> None:
+        """Restrict which releases are considered when looking for updates
+
+        Args:
+            tag_pattern: fnmatch-style glob the tag must match, e.g. "v*-win"
+            ignored_tags: Tags that are never considered
+            ignore_drafts: Skip draft releases (only listed with push access)
+        """
+        self.tag_pattern = tag_pattern or ""
+        self.ignored_tags = list(ignored_tags or [])
+        self.ignore_drafts = ignore_drafts
+
+    def set_channel(self, channel: str) -> None:
+        """Select the release channel: stable, prerelease or nightly"""
+        self.channel = channel or "stable"
+
+    def matches_channel(self, data: Dict[str, Any]) -> bool:
+        """Check a GitHub release object against the release channel
+
+        stable takes neither prereleases nor nightly tags (as
+        /releases/latest does), prerelease adds preview builds, and
+        nightly only takes tags such as "nightly-20240101" or "v0.150-nightly".
+        """
+        nightly = bool(self.NIGHTLY_TAG.search(data.get('tag_name', '')))
+        if self.channel == "nightly":
+            return nightly
+        if self.channel == "prerelease":
+            return not nightly
+        return not nightly and not data.get('prerelease')
+
+    @staticmethod
+    def detect_platform() -> Tuple[str, str]:
+        """Platform and architecture of this machine in asset naming terms"""
+        system = {'Windows': 'windows', 'Darwin': 'macos'}.get(platform.system(), platform.system().lower())
+        machine = platform.machine().lower()
+        arch = {'amd64': 'x86_64', 'x64': 'x86_64', 'arm64': 'aarch64'}.get(machine, machine)
+        return system, arch
+
+    def set_platform(self, system: str = "", arch: str = "") -> None:
+        """Choose assets for another platform or architecture, empty to detect"""
+        detected_system, detected_arch = self.detect_platform()
+        self.platform = (system or detected_system).lower()
+        self.arch = (arch or detected_arch).lower()
+
+    def set_asset_pattern(self, pattern: str) -> None:
+        """Only consider assets whose name matches a regular expression, empty for all"""
+        self.asset_pattern = None
+        if pattern:
+            try:
+                self.asset_pattern = re.compile(pattern)
+            except re.error as e:
+                self.logger.error(f"Invalid asset pattern '{pattern}': {e}, ignoring it")
+
+    def _has_keyword(self, name: str, keyword: str) -> bool:
+        return re.search(rf'(?<![a-z0-9]){re.escape(keyword)}(?![a-z0-9])', name) is not None
+
+    def _asset_score(self, filename: str) -> Optional[int]:
+        """How well an asset fits the platform and architecture, None if it cannot run here
+
+        Naming the platform scores highest, a file type typical for it
+        (.exe, .tar.gz, ...) next. An asset for another platform is never
+        picked. One for another architecture only loses points, since
+        Windows and macOS on ARM run x86_64 builds too.
+        """
+        name = filename.lower()
+        named_platforms = {system for system, keywords in self.PLATFORM_KEYWORDS.items()
+                           if any(self._has_keyword(name, keyword) for keyword in keywords)}
+        named_arches = {arch for arch, keywords in self.ARCH_KEYWORDS.items()
+                        if any(self._has_keyword(name, keyword) for keyword in keywords)}
+
+        if self.platform in named_platforms:
+            score = 4
+        elif named_platforms:
+            return None
+        elif name.endswith(self.PLATFORM_SUFFIXES.get(self.platform, ())):
+            score = 2
+        elif any(name.endswith(suffixes) for system, suffixes in self.PLATFORM_SUFFIXES.items()
+                 if system != self.platform):
+            return None
+        else:
+            score = 0
+
+        if self.arch in named_arches:
+            score += 1
+        elif named_arches:
+            score -= 1
+        return score
+
+    def set_language_preference(self, languages: List[str]) -> None:
+        """Prefer localized assets, most wanted language first (e.g. ["zh-CN", "en"])"""
+        self.languages = [language for language in languages if language]
+
+    def _matches_language(self, filename: str, language: str) -> bool:
+        """Check if an asset name carries a language tag
+
+        zh_CN, zh-CN and zhCN are treated alike, and a bare "zh" matches
+        every Chinese variant.
+        """
+        parts = re.split(r'[-_]', language.lower())
+        pattern = r'[-_]?'.join(re.escape(part) for part in parts)
+        return re.search(rf'(?<![a-z]){pattern}(?![a-z])', filename.lower()) is not None
+
+    def is_release_allowed(self, data: Dict[str, Any]) -> bool:
+        """Check a GitHub release object against the release filter"""
+        tag_name = data.get('tag_name', '')
+        if self.ignore_drafts and data.get('draft'):
+            return False
+        if tag_name in self.ignored_tags or tag_name.lstrip('v') in self.ignored_tags:
+            return False
+        if self.tag_pattern and not fnmatch.fnmatchcase(tag_name, self.tag_pattern):
+            return False
+        return True
+
+    def _parse_release(self, data: Dict[str, Any]) -> ReleaseInfo:
+        """Build ReleaseInfo from a GitHub release object"""
+        # Drafts have no publication date yet
+        published = data.get('published_at') or data['created_at']
+        release_date = datetime.fromisoformat(published.replace('Z', '+00:00'))
+        assets = [self._parse_asset(asset_data) for asset_data in data.get('assets', [])]
+
+        # Take the asset that best fits this platform and architecture, see
+        # _asset_score. Patches, checksum files and formats install_update
+        # cannot handle are never candidates, not even for asset_pattern.
+        scored = [(self._asset_score(asset.name), asset) for asset in assets
+                  if self._is_installable_asset(asset.name)]
+        if self.asset_pattern:
+            # The pattern is the user's call, it may pick what the heuristic rules out
+            scored = [(-10 if score is None else score, asset) for score, asset in scored
+                      if self.asset_pattern.search(asset.name)]
+        scored = [(score, asset) for score, asset in scored if score is not None]
+        best = max((score for score, _ in scored), default=None)
+        candidates = [asset for score, asset in scored if score == best]
+        selected = candidates[0] if candidates else None
+
+        # Among localized builds take the first language that has one
+        for language in self.languages:
+            localized = [asset for asset in candidates if self._matches_language(asset.name, language)]
+            if localized:
+                selected = localized[0]
+                break
+
+        # Extract version from tag
+        tag_name = data.get('tag_name', '')
+        version = tag_name.lstrip('v') if tag_name else 'latest'
+
+        return ReleaseInfo(
+            version=version,
+            release_date=release_date,
+            download_url=selected.download_url if selected else "",
+            description=data.get('body', ''),
+            size=selected.size if selected else 0,
+            sha256=selected.sha256 if selected else None,
+            assets=assets,
+            asset_api_url=selected.api_url if selected else "",
+            prerelease=bool(data.get('prerelease')),
+            source=self.describe()
+        )
+
+    def _parse_asset(self, asset_data: Dict[str, Any]) -> ReleaseAsset:
+        """Build ReleaseAsset from a GitHub asset object"""
+        return ReleaseAsset(
+            name=asset_data['name'],
+            download_url=asset_data['browser_download_url'],
+            size=asset_data['size'],
+            content_type=asset_data.get('content_type', ''),
+            api_url=asset_data.get('url', ''),
+            sha256=self._parse_digest(asset_data.get('digest'))
+        )
+
+    def _parse_digest(self, digest: Optional[str]) -> Optional[str]:
+        """SHA-256 from an asset digest such as ``sha256:<hex>``, None for other algorithms"""
+        algorithm, _, value = (digest or '').partition(':')
+        if algorithm.lower() == 'sha256' and len(value) == 64:
+            return value.lower()
+        return None
+
+    def _is_installable_asset(self, filename: str) -> bool:
+        """Check if an asset is Zed itself, an installer or an archive holding Zed"""
+        return (filename.lower().endswith(self.INSTALLABLE_SUFFIXES) and
+                not self._is_auxiliary_asset(filename))
+
+    def _is_auxiliary_asset(self, filename: str) -> bool:
+        """Check if an asset is a delta patch or checksum file"""
         filename_lower = filename.lower()
-        return (filename_lower.endswith('.exe') or
-                filename_lower.endswith('.msi') or
-                'windows' in filename_lower)
+        return filename_lower.endswith(self.DELTA_SUFFIXES + (self.CHECKSUM_SUFFIX,))
+
+    def find_delta_asset(self, release_info: ReleaseInfo, from_version: str) -> Optional[ReleaseAsset]:
+        """Find a binary patch from from_version to this release
+
+        Patch assets are expected to carry both versions in their name,
+        e.g. ``zed-0.150.1-to-0.151.0.patch``.
+        """
+        from_version = from_version.lstrip('v')
+        for asset in release_info.assets:
+            name = asset.name.lower()
+            if (name.endswith(self.DELTA_SUFFIXES) and
+                    from_version in name and release_info.version in name):
+                return asset
+        return None
+
+    def get_asset_checksum(self, release_info: ReleaseInfo, asset_name: str) -> Optional[str]:
+        """Read the SHA-256 published as ``<asset_name>.sha256``, None if absent"""
+        checksum_name = asset_name + self.CHECKSUM_SUFFIX
+        asset = next((a for a in release_info.assets if a.name == checksum_name), None)
+        if not asset:
+            return None
+
+        url, headers = self.get_asset_request(asset)
+        try:
+            response = self.session.get(url, headers=headers, timeout=self.REQUEST_TIMEOUT)
+            response.raise_for_status()
+        except requests.exceptions.RequestException as e:
+            self.logger.warning(f"Failed to fetch checksum {checksum_name}: {e}")
+            return None
+
+        # sha256sum format: "<hex>  <filename>"
+        parts = response.text.split()
+        if parts and len(parts[0]) == 64:
+            return parts[0].lower()
+        self.logger.warning(f"Malformed checksum file: {checksum_name}")
+        return None
+
+    def get_asset_request(self, asset: ReleaseAsset) -> Tuple[str, Dict[str, str]]:
+        """Get URL and headers to download any asset, see get_asset_download_request"""
+        headers = self._auth_headers_for(asset.api_url)
+        if headers and not self._downloads_bypass_api(asset.download_url):
+            return asset.api_url, dict(headers, Accept='application/octet-stream')
+        return asset.download_url, {}
+
+    def get_asset_download_request(self, release_info: ReleaseInfo) -> Tuple[str, Dict[str, str]]:
+        """Get URL and headers to download the selected asset of a release
+
+        With a token the asset is fetched through the API endpoint, which
+        is the only way to download assets of private repositories
+        (browser_download_url returns 404 for them). GitHub answers with a
+        redirect to the storage host, and requests drops the Authorization
+        header when following it.
+
+        While the API is rate limited, assets are taken from
+        browser_download_url instead, which the limit does not apply to
+        and which download mirrors can serve. That only works for public
+        repositories.
+        """
+        headers = self._auth_headers_for(release_info.asset_api_url)
+        if headers and not self._downloads_bypass_api(release_info.download_url):
+            return release_info.asset_api_url, dict(headers, Accept='application/octet-stream')
+        return release_info.download_url, {}
+
+    def _downloads_bypass_api(self, download_url: str) -> bool:
+        """Whether to skip the rate-limited API for an asset that has a public URL"""
+        if not download_url or not self.is_rate_limited():
+            return False
+        self.logger.info(f"API rate limited, downloading from {download_url} instead")
+        return True
 
     def set_proxy(self, proxy_url: str) -> None:
         """Set proxy for requests"""
@@ -0,0 +1,72 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+GitLab release source for Zed Updater
+"""
+
+from typing import Dict, Any, Optional
+from urllib.parse import quote, urlparse
+
+from .github_api import GitHubAPI
+
+
+class GitLabAPI(GitHubAPI):
+    """Fetch Zed builds from the releases of a GitLab project
+
+    Works with gitlab.com and self-hosted instances. The project is given
+    as its path ("group/zed-builds") or numeric ID. Release links are the
+    downloadable assets; GitLab reports no sizes for them, so the size is
+    taken from the download itself.
+    """
+
+    SOURCE_NAME = "GitLab"
+    DEFAULT_URL = "https://gitlab.com"
+
+    def __init__(self, repo: str, base_url: Optional[str] = None, token: Optional[str] = None):
+        base_url = (base_url or self.DEFAULT_URL).rstrip('/')
+        super().__init__(repo, api_url=f"{base_url}/api/v4", token=token)
+        self.host = urlparse(base_url).netloc
+
+    def _auth_headers(self) -> Dict[str, str]:
+        return {'PRIVATE-TOKEN': self.token} if self.token else {}
+
+    def _project(self) -> str:
+        return quote(self.repo, safe='')
+
+    def _latest_release_endpoint(self) -> str:
+        return f"/projects/{self._project()}/releases/permalink/latest"
+
+    def _releases_endpoint(self) -> str:
+        return f"/projects/{self._project()}/releases"
+
+    def _release_by_tag_endpoint(self, tag: str) -> str:
+        return f"/projects/{self._project()}/releases/{quote(tag, safe='')}"
+
+    def _rate_limit_endpoint(self) -> Optional[str]:
+        # GitLab has no endpoint reporting the limit
+        return None
+
+    def _normalize_release(self, data: Dict[str, Any]) -> Dict[str, Any]:
+        links = (data.get('assets') or {}).get('links') or []
+
+        def private_url(link: Dict[str, Any]) -> str:
+            # Links may point anywhere, the token only goes to the instance itself
+            url = link.get('direct_asset_url') or link.get('url', '')
+            return url if urlparse(url).netloc == self.host else ''
+
+        return {
+            'tag_name': data.get('tag_name', ''),
+            'published_at': data.get('released_at') or data.get('created_at'),
+            'body': data.get('description') or '',
+            # Releases scheduled for the future are GitLab's closest thing to prereleases
+            'prerelease': bool(data.get('upcoming_release')),
+            'draft': False,
+            'assets': [{
+                'name': link.get('name', ''),
+                'browser_download_url': link.get('direct_asset_url') or link.get('url', ''),
+                'size': 0,
+                'content_type': '',
+                # Lets get_asset_request send the token for private projects
+                'url': private_url(link),
+            } for link in links],
+        }
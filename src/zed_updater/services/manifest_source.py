@@ -0,0 +1,111 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Update manifest release source for Zed Updater
+"""
+
+from datetime import datetime, timezone
+from typing import Dict, Any, List, Optional
+from urllib.parse import urlparse
+
+from .github_api import GitHubAPI, ReleaseInfo
+
+
+class ManifestSource(GitHubAPI):
+    """Read releases from a JSON manifest on any web server or CDN
+
+    The manifest describes one release or lists several, newest first::
+
+        {"version": "0.151.0", "url": "https://cdn.example.com/zed.exe",
+         "sha256": "...", "notes": "...", "date": "2024-06-01"}
+
+        {"releases": [{"version": "0.151.0", ...}, {"version": "0.150.2", ...}]}
+
+    "size" and "prerelease" are optional as well. The sha256, when given,
+    is checked after downloading.
+    """
+
+    SOURCE_NAME = "manifest"
+
+    def __init__(self, manifest_url: str, token: Optional[str] = None):
+        super().__init__("", token=token)
+        # Endpoints are the manifest URL itself
+        self.api_base = ""
+        self.manifest_url = manifest_url
+        # Not GitHub, so no GitHub media type
+        self.session.headers['Accept'] = 'application/json'
+
+    def describe(self) -> str:
+        return f"{self.SOURCE_NAME}:{self.manifest_url}"
+
+    def _auth_headers_for(self, url: str) -> Dict[str, str]:
+        # The token belongs to the manifest's server, not to the hosts it links to
+        if urlparse(url).netloc != urlparse(self.manifest_url).netloc:
+            return {}
+        return self._auth_headers()
+
+    def _latest_release_endpoint(self) -> str:
+        return self.manifest_url
+
+    def _releases_endpoint(self) -> str:
+        return self.manifest_url
+
+    def _release_by_tag_endpoint(self, tag: str) -> str:
+        return self.manifest_url
+
+    def _page_params(self, per_page: int, page: int = 1) -> Dict[str, Any]:
+        # A static file cannot be paged, and the query would defeat caching
+        return {}
+
+    def _rate_limit_endpoint(self) -> Optional[str]:
+        return None
+
+    def _normalize_response(self, data: Any) -> Any:
+        if isinstance(data, dict) and isinstance(data.get('releases'), list):
+            entries = data['releases']
+        elif isinstance(data, dict):
+            entries = [data]
+        else:
+            entries = data if isinstance(data, list) else []
+        return [self._normalize_release(entry) for entry in entries
+                if isinstance(entry, dict) and entry.get('version') and entry.get('url')]
+
+    def _normalize_release(self, data: Dict[str, Any]) -> Dict[str, Any]:
+        url = data['url']
+        return {
+            'tag_name': str(data['version']),
+            'published_at': str(data.get('date') or datetime.now(timezone.utc).isoformat()),
+            'body': data.get('notes') or '',
+            'prerelease': bool(data.get('prerelease')),
+            'draft': False,
+            'sha256': data.get('sha256'),
+            'assets': [{
+                'name': urlparse(url).path.rsplit('/', 1)[-1] or 'zed.exe',
+                'browser_download_url': url,
+                'size': int(data.get('size') or 0),
+                'content_type': '',
+                'url': '',
+            }],
+        }
+
+    def _parse_release(self, data: Dict[str, Any]) -> ReleaseInfo:
+        release_info = super()._parse_release(data)
+        release_info.sha256 = (data.get('sha256') or '').lower() or None
+        return release_info
+
+    def get_latest_release(self) -> Optional[ReleaseInfo]:
+        """Newest manifest entry passing the channel and release filter"""
+        return self._get_latest_filtered_release()
+
+    def get_release_by_tag(self, tag: str) -> Optional[ReleaseInfo]:
+        """Manifest entry for a version, with or without a leading v"""
+        for release_info in self.get_releases(100):
+            if release_info.version == tag.lstrip('v'):
+                return release_info
+        return None
+
+    def get_releases(self, count: int = 10, page: int = 1) -> List[ReleaseInfo]:
+        """Page through the manifest entries"""
+        releases = super().get_releases(100)
+        start = (max(page, 1) - 1) * count
+        return releases[start:start + count]
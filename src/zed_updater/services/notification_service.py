@@ -54,6 +54,12 @@ class NotificationService:
         message = f"发现新版本 {version}，是否现在更新？"
         self.show_notification(title, message, "info")
 
+    def show_update_ready(self, version: str) -> None:
+        """Show update downloaded and ready to install notification"""
+        title = "Zed 更新已就绪"
+        message = f"新版本 {version} 已下载完成，点击更新即可立即安装"
+        self.show_notification(title, message, "info")
+
     def show_update_completed(self, version: str) -> None:
         """Show update completed notification"""
         title = "Zed 更新完成"
@@ -66,6 +72,10 @@ class NotificationService:
         message = f"更新过程中出现错误：{error}"
         self.show_notification(title, message, "error")
 
+    def show_update_crashed(self, message: str) -> None:
+        """Show notification that Zed crashed right after an update"""
+        self.show_notification("Zed 可能更新失败", message, "error")
+
     def show_backup_created(self, path: str) -> None:
         """Show backup created notification"""
         title = "Zed 备份完成"
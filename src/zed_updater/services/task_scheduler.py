@@ -0,0 +1,199 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+OS task scheduler integration - runs scheduled checks when the updater is not running
+"""
+
+import os
+import platform
+import shlex
+import subprocess
+import tempfile
+from datetime import datetime
+from typing import List
+from xml.sax.saxutils import escape
+
+from ..utils.logger import get_logger
+
+
+class SystemTaskScheduler:
+    """Register a scheduled update check with Windows Task Scheduler or cron"""
+
+    TASK_NAME = "ZedUpdater"
+    CRON_MARKER = "# zed-updater scheduled check"
+
+    def __init__(self):
+        self.logger = get_logger(__name__)
+        self.is_windows = platform.system() == "Windows"
+
+    def register(self, command: List[str], check_time: str = "", interval_hours: int = 24) -> bool:
+        """Register (or replace) the scheduled task
+
+        Args:
+            command: Command line to run
+            check_time: Daily run time as HH:MM, empty to run every interval_hours
+            interval_hours: Interval used when check_time is empty
+        """
+        try:
+            if self.is_windows:
+                return self._register_windows(command, check_time, interval_hours)
+            return self._register_cron(command, check_time, interval_hours)
+        except (OSError, subprocess.SubprocessError, ValueError) as e:
+            self.logger.error(f"注册计划任务失败: {e}")
+            return False
+
+    def unregister(self) -> bool:
+        """Remove the scheduled task, True if nothing is left registered"""
+        try:
+            if self.is_windows:
+                if not self.is_registered():
+                    return True
+                result = subprocess.run(
+                    ['schtasks', '/Delete', '/TN', self.TASK_NAME, '/F'],
+                    capture_output=True, text=True
+                )
+                if result.returncode != 0:
+                    self.logger.error(f"删除计划任务失败: {result.stderr.strip()}")
+                    return False
+            else:
+                lines = self._read_crontab()
+                kept = [line for line in lines if self.CRON_MARKER not in line]
+                if kept != lines:
+                    self._write_crontab(kept)
+
+            self.logger.info("计划任务已删除")
+            return True
+
+        except (OSError, subprocess.SubprocessError) as e:
+            self.logger.error(f"删除计划任务失败: {e}")
+            return False
+
+    def is_registered(self) -> bool:
+        """Check if the scheduled task exists"""
+        try:
+            if self.is_windows:
+                result = subprocess.run(
+                    ['schtasks', '/Query', '/TN', self.TASK_NAME],
+                    capture_output=True, text=True
+                )
+                return result.returncode == 0
+            return any(self.CRON_MARKER in line for line in self._read_crontab())
+        except (OSError, subprocess.SubprocessError):
+            return False
+
+    def _register_windows(self, command: List[str], check_time: str, interval_hours: int) -> bool:
+        xml = self.build_task_xml(command, check_time, interval_hours)
+
+        # schtasks only accepts WakeToRun through an XML definition
+        fd, xml_path = tempfile.mkstemp(suffix='.xml')
+        try:
+            with os.fdopen(fd, 'w', encoding='utf-16') as f:
+                f.write(xml)
+            result = subprocess.run(
+                ['schtasks', '/Create', '/TN', self.TASK_NAME, '/XML', xml_path, '/F'],
+                capture_output=True, text=True
+            )
+        finally:
+            os.unlink(xml_path)
+
+        if result.returncode != 0:
+            self.logger.error(f"注册计划任务失败: {result.stderr.strip()}")
+            return False
+
+        self.logger.info(f"已注册 Windows 计划任务: {self.TASK_NAME}")
+        return True
+
+    def _register_cron(self, command: List[str], check_time: str, interval_hours: int) -> bool:
+        lines = [line for line in self._read_crontab() if self.CRON_MARKER not in line]
+        lines.append(self.build_cron_line(command, check_time, interval_hours))
+        self._write_crontab(lines)
+        self.logger.info("已注册 cron 计划任务")
+        return True
+
+    def build_task_xml(self, command: List[str], check_time: str, interval_hours: int) -> str:
+        """Build a Task Scheduler definition that wakes the machine to run"""
+        program = escape(command[0])
+        arguments = escape(subprocess.list2cmdline(command[1:]))
+
+        if check_time:
+            hour, minute = self._parse_time(check_time)
+            start = datetime.now().replace(hour=hour, minute=minute, second=0, microsecond=0)
+            trigger = (
+                "    <CalendarTrigger>\n"
+                f"      <StartBoundary>{start.isoformat()}</StartBoundary>\n"
+                "      <ScheduleByDay><DaysInterval>1</DaysInterval></ScheduleByDay>\n"
+                "    </CalendarTrigger>"
+            )
+        else:
+            start = datetime.now().replace(second=0, microsecond=0)
+            trigger = (
+                "    <TimeTrigger>\n"
+                f"      <StartBoundary>{start.isoformat()}</StartBoundary>\n"
+                f"      <Repetition><Interval>PT{max(int(interval_hours), 1)}H</Interval></Repetition>\n"
+                "    </TimeTrigger>"
+            )
+
+        return (
+            '<?xml version="1.0" encoding="UTF-16"?>\n'
+            '<Task version="1.2" xmlns="http://schemas.microsoft.com/windows/2004/02/mit/task">\n'
+            "  <Triggers>\n"
+            f"{trigger}\n"
+            "  </Triggers>\n"
+            "  <Settings>\n"
+            "    <WakeToRun>true</WakeToRun>\n"
+            "    <StartWhenAvailable>true</StartWhenAvailable>\n"
+            "    <DisallowStartIfOnBatteries>false</DisallowStartIfOnBatteries>\n"
+            "    <MultipleInstancesPolicy>IgnoreNew</MultipleInstancesPolicy>\n"
+            "  </Settings>\n"
+            "  <Actions>\n"
+            "    <Exec>\n"
+            f"      <Command>{program}</Command>\n"
+            f"      <Arguments>{arguments}</Arguments>\n"
+            "    </Exec>\n"
+            "  </Actions>\n"
+            "</Task>\n"
+        )
+
+    def build_cron_line(self, command: List[str], check_time: str, interval_hours: int) -> str:
+        """Build a crontab entry for the scheduled check"""
+        if check_time:
+            hour, minute = self._parse_time(check_time)
+            schedule = f"{minute} {hour} * * *"
+        else:
+            interval_hours = max(int(interval_hours), 1)
+            if interval_hours < 24:
+                schedule = f"0 */{interval_hours} * * *"
+            else:
+                schedule = f"0 0 */{max(interval_hours // 24, 1)} * *"
+
+        quoted = " ".join(shlex.quote(part) for part in command)
+        # cron turns an unescaped % into a newline, even inside quotes
+        quoted = quoted.replace('%', '\\%')
+        return f"{schedule} {quoted} {self.CRON_MARKER}"
+
+    def _parse_time(self, check_time: str):
+        hour, minute = map(int, check_time.split(':'))
+        if not (0 <= hour < 24 and 0 <= minute < 60):
+            raise ValueError(f"Invalid check time: {check_time}")
+        return hour, minute
+
+    def _read_crontab(self) -> List[str]:
+        """Current crontab lines
+
+        Raises:
+            OSError: If crontab -l fails for any reason other than there
+                being no crontab yet. Writing back what was read would
+                otherwise wipe the user's entries.
+        """
+        result = subprocess.run(['crontab', '-l'], capture_output=True, text=True)
+        if result.returncode != 0:
+            if 'no crontab for' in result.stderr.lower():
+                return []
+            raise OSError(f"crontab -l failed: {result.stderr.strip() or result.returncode}")
+        return result.stdout.splitlines()
+
+    def _write_crontab(self, lines: List[str]) -> None:
+        content = "\n".join(lines) + "\n" if lines else ""
+        result = subprocess.run(['crontab', '-'], input=content, capture_output=True, text=True)
+        if result.returncode != 0:
+            raise OSError(f"crontab failed: {result.stderr.strip()}")
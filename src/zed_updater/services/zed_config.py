@@ -0,0 +1,107 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Backup and restore of Zed's own settings and extensions
+"""
+
+import os
+import shutil
+import tempfile
+import platform
+import zipfile
+from pathlib import Path
+from typing import Dict, Iterable, List, Optional
+
+from ..utils.archive import extract_archive
+from ..utils.logger import get_logger
+
+
+class ZedConfigBackup:
+    """Archive Zed's configuration directory and installed extensions
+
+    The archive holds ``config/`` (settings.json, keymap.json, themes/ and
+    everything else in Zed's config directory) and, if requested,
+    ``extensions/`` with the installed extensions. Extension work
+    directories (downloaded language servers) are left out, Zed fetches
+    them again on demand.
+    """
+
+    PARTS = ('config', 'extensions')
+
+    def __init__(self):
+        self.logger = get_logger(__name__)
+        self.system = platform.system()
+
+    def get_locations(self) -> Dict[str, Path]:
+        """Zed's directories on this platform, keyed like PARTS"""
+        home = Path.home()
+
+        if self.system == "Windows":
+            roaming = Path(os.environ.get('APPDATA', home / 'AppData' / 'Roaming'))
+            local = Path(os.environ.get('LOCALAPPDATA', home / 'AppData' / 'Local'))
+            return {
+                'config': roaming / 'Zed',
+                'extensions': local / 'Zed' / 'extensions' / 'installed',
+            }
+
+        config_home = Path(os.environ.get('XDG_CONFIG_HOME', home / '.config'))
+        if self.system == "Darwin":
+            data_dir = home / 'Library' / 'Application Support' / 'Zed'
+        else:
+            data_dir = Path(os.environ.get('XDG_DATA_HOME', home / '.local' / 'share')) / 'zed'
+        return {
+            'config': config_home / 'zed',
+            'extensions': data_dir / 'extensions' / 'installed',
+        }
+
+    def create(self, archive_path: Path, parts: Iterable[str]) -> bool:
+        """Write the given parts to a zip archive
+
+        Returns:
+            False if none of the directories exist, nothing is written then
+        """
+        locations = self.get_locations()
+        sources = [(part, locations[part]) for part in parts if locations[part].is_dir()]
+        if not sources:
+            return False
+
+        tmp_path = archive_path.with_name(archive_path.name + '.tmp')
+        with zipfile.ZipFile(tmp_path, 'w', zipfile.ZIP_DEFLATED) as archive:
+            for part, directory in sources:
+                for path in sorted(directory.rglob('*')):
+                    if path.is_file() and not path.is_symlink():
+                        archive.write(path, f"{part}/{path.relative_to(directory).as_posix()}")
+        tmp_path.replace(archive_path)
+
+        self.logger.info(f"已备份 Zed 配置: {archive_path}")
+        return True
+
+    def restore(self, archive_path: Path, parts: Iterable[str],
+                temp_dir: Optional[Path] = None) -> List[str]:
+        """Copy the given parts from an archive back over Zed's directories
+
+        Files in the archive overwrite their current versions, files that
+        were added since the backup are kept. The archive is unpacked in
+        temp_dir, the system temp directory if not given.
+
+        Returns:
+            The parts that were restored
+        """
+        locations = self.get_locations()
+        restored = []
+
+        with tempfile.TemporaryDirectory(prefix='zed_config_restore_', dir=temp_dir) as tmp_dir:
+            extract_archive(archive_path, Path(tmp_dir))
+            for part in parts:
+                source = Path(tmp_dir) / part
+                if not source.is_dir():
+                    continue
+                for path in source.rglob('*'):
+                    if path.is_file():
+                        target = locations[part] / path.relative_to(source)
+                        target.parent.mkdir(parents=True, exist_ok=True)
+                        shutil.copy2(path, target)
+                restored.append(part)
+                self.logger.info(f"已恢复 Zed {part}: {locations[part]}")
+
+        return restored
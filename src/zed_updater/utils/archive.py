@@ -0,0 +1,123 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Archive extraction for zip and tar.gz release assets
+"""
+
+import os
+import tarfile
+import zipfile
+from pathlib import Path, PurePosixPath
+from typing import Iterable, Optional, Union
+
+
+# Recognised archive suffixes, longest first
+ARCHIVE_SUFFIXES = ('.tar.gz', '.tgz', '.zip')
+
+
+def archive_suffix(filename: str) -> Optional[str]:
+    """Get the archive suffix of a file name, None if it is not an archive"""
+    name = filename.lower()
+    for suffix in ARCHIVE_SUFFIXES:
+        if name.endswith(suffix):
+            return suffix
+    return None
+
+
+def _safe_target(dest_dir: Path, member_name: str) -> Path:
+    """Resolve an archive member below dest_dir, refusing path traversal"""
+    target = (dest_dir / member_name).resolve()
+    if target != dest_dir and dest_dir not in target.parents:
+        raise ValueError(f"Archive member escapes extraction directory: {member_name}")
+    return target
+
+
+def _extract_zip(archive_path: Path, dest_dir: Path) -> None:
+    with zipfile.ZipFile(archive_path) as archive:
+        for info in archive.infolist():
+            target = _safe_target(dest_dir, info.filename)
+            if info.is_dir():
+                target.mkdir(parents=True, exist_ok=True)
+                continue
+
+            target.parent.mkdir(parents=True, exist_ok=True)
+            with archive.open(info) as src, open(target, 'wb') as dst:
+                while True:
+                    chunk = src.read(1024 * 1024)
+                    if not chunk:
+                        break
+                    dst.write(chunk)
+
+            # ZipFile.extract drops Unix permissions, restore them by hand
+            mode = (info.external_attr >> 16) & 0o777
+            if mode:
+                os.chmod(target, mode)
+
+
+def _extract_tar(archive_path: Path, dest_dir: Path) -> None:
+    with tarfile.open(archive_path, 'r:*') as archive:
+        for member in archive.getmembers():
+            _safe_target(dest_dir, member.name)
+            if not (member.isfile() or member.isdir()):
+                # Links and device nodes have no place in a release archive
+                continue
+            member.mode &= 0o777
+            archive.extract(member, dest_dir)
+
+
+def extract_archive(archive_path: Union[str, Path], dest_dir: Union[str, Path]) -> Path:
+    """Extract a zip or tar.gz archive, preserving file permissions
+
+    Returns:
+        The extraction directory
+
+    Raises:
+        ValueError: If the archive type is unsupported or a member would be
+            written outside dest_dir
+    """
+    archive_path = Path(archive_path)
+    dest_dir = Path(dest_dir)
+    dest_dir.mkdir(parents=True, exist_ok=True)
+    dest_dir = dest_dir.resolve()
+
+    if zipfile.is_zipfile(archive_path):
+        _extract_zip(archive_path, dest_dir)
+    elif tarfile.is_tarfile(archive_path):
+        _extract_tar(archive_path, dest_dir)
+    else:
+        raise ValueError(f"Unsupported archive format: {archive_path.name}")
+
+    return dest_dir
+
+
+def find_binary(extract_dir: Union[str, Path], inner_path: str = "",
+                names: Iterable[str] = ()) -> Optional[Path]:
+    """Locate the executable inside an extracted archive
+
+    Args:
+        extract_dir: Directory the archive was extracted to
+        inner_path: Explicit path of the binary inside the archive
+        names: File names to search for when inner_path is empty,
+            compared case-insensitively, in order of preference
+
+    Returns:
+        Path to the binary or None if not found
+    """
+    extract_dir = Path(extract_dir)
+
+    if inner_path:
+        candidate = _safe_target(extract_dir.resolve(), str(PurePosixPath(inner_path)))
+        return candidate if candidate.is_file() else None
+
+    files = [path for path in extract_dir.rglob('*') if path.is_file()]
+    # Prefer the shallowest match, archives often nest a top-level folder
+    files.sort(key=lambda path: (len(path.relative_to(extract_dir).parts), str(path)))
+
+    for name in names:
+        name = name.lower()
+        for path in files:
+            if path.name.lower() == name:
+                return path
+
+    return None
+
@@ -0,0 +1,65 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Token storage in the OS credential store
+"""
+
+from typing import Optional
+
+from .logger import get_logger
+
+
+class CredentialStore:
+    """Keep secrets in Windows Credential Manager, the macOS keychain or
+    the Secret Service (libsecret) instead of the config file
+
+    Uses the optional keyring package. Without it, or without a usable
+    backend (e.g. a headless Linux box), available is False and callers
+    keep the secret in the config file as before.
+    """
+
+    SERVICE = "zed-updater"
+
+    def __init__(self):
+        self.logger = get_logger(__name__)
+        self._keyring = None
+        try:
+            import keyring
+            from keyring.backends.fail import Keyring as FailKeyring
+        except ImportError:
+            return
+        if not isinstance(keyring.get_keyring(), FailKeyring):
+            self._keyring = keyring
+
+    @property
+    def available(self) -> bool:
+        return self._keyring is not None
+
+    def get(self, name: str) -> Optional[str]:
+        """Stored secret, None if missing or the store can't be read"""
+        if not self.available:
+            return None
+        try:
+            return self._keyring.get_password(self.SERVICE, name)
+        except Exception as e:
+            self.logger.warning(f"读取凭据 {name} 失败: {e}")
+            return None
+
+    def set(self, name: str, value: str) -> bool:
+        if not self.available:
+            return False
+        try:
+            self._keyring.set_password(self.SERVICE, name, value)
+            return True
+        except Exception as e:
+            self.logger.warning(f"保存凭据 {name} 失败: {e}")
+            return False
+
+    def delete(self, name: str) -> None:
+        if not self.available:
+            return
+        try:
+            self._keyring.delete_password(self.SERVICE, name)
+        except Exception:
+            # Nothing stored under that name
+            pass
@@ -0,0 +1,97 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+File type detection by magic bytes
+"""
+
+from pathlib import Path
+from typing import Optional, Tuple, Union
+
+
+# Leading byte signatures, checked in order
+MAGIC_SIGNATURES = [
+    (b'MZ', 'pe'),
+    (b'\x7fELF', 'elf'),
+    (b'\xfe\xed\xfa\xce', 'macho'),
+    (b'\xfe\xed\xfa\xcf', 'macho'),
+    (b'\xce\xfa\xed\xfe', 'macho'),
+    (b'\xcf\xfa\xed\xfe', 'macho'),
+    (b'\xca\xfe\xba\xbe', 'macho'),  # Universal binary
+    (b'PK\x03\x04', 'zip'),
+    (b'PK\x05\x06', 'zip'),  # Empty archive
+    (b'\x1f\x8b', 'gzip'),
+    (b'\xfd7zXZ\x00', 'xz'),
+    (b'BZh', 'bzip2'),
+    (b'7z\xbc\xaf\x27\x1c', '7z'),
+    (b'\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1', 'msi'),  # OLE compound file
+]
+
+# File types accepted for each asset extension, longest suffix first
+EXTENSION_TYPES = [
+    ('.tar.gz', ('gzip',)),
+    ('.tgz', ('gzip',)),
+    ('.tar.xz', ('xz',)),
+    ('.tar.bz2', ('bzip2',)),
+    ('.exe', ('pe',)),
+    ('.msi', ('msi',)),
+    ('.zip', ('zip',)),
+    ('.7z', ('7z',)),
+    ('.gz', ('gzip',)),
+    ('.appimage', ('elf',)),
+]
+
+EXECUTABLE_TYPES = ('pe', 'elf', 'macho')
+
+_MARKUP_PREFIXES = (b'<!doctype', b'<html', b'<head', b'<?xml', b'<body')
+
+
+def detect_file_type(path: Union[str, Path], sample_size: int = 512) -> str:
+    """Detect a file's type from its leading bytes
+
+    Returns one of the MAGIC_SIGNATURES names, 'html' for markup (the
+    typical captive portal or proxy error page), 'empty' or 'unknown'.
+    """
+    with open(path, 'rb') as f:
+        header = f.read(sample_size)
+
+    if not header:
+        return 'empty'
+
+    for signature, file_type in MAGIC_SIGNATURES:
+        if header.startswith(signature):
+            return file_type
+
+    text = header.lstrip(b'\xef\xbb\xbf \t\r\n').lower()
+    if text.startswith(_MARKUP_PREFIXES):
+        return 'html'
+
+    return 'unknown'
+
+
+def expected_types(filename: str) -> Optional[Tuple[str, ...]]:
+    """Get the file types an asset name implies, None if the extension is not known"""
+    name = filename.lower()
+    for suffix, types in EXTENSION_TYPES:
+        if name.endswith(suffix):
+            return types
+    return None
+
+
+def validate_file_type(path: Union[str, Path], filename: Optional[str] = None) -> Tuple[bool, str]:
+    """Check that a file's content matches what its name promises
+
+    Args:
+        path: File to inspect
+        filename: Asset name to derive the expected type from (defaults to path's name)
+
+    Returns:
+        (is_valid, detected_type)
+    """
+    detected = detect_file_type(path)
+    if detected in ('html', 'empty'):
+        return False, detected
+
+    expected = expected_types(filename or Path(path).name)
+    if expected is None:
+        return True, detected
+    return detected in expected, detected
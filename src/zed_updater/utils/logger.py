@@ -5,6 +5,8 @@ Logger utility for Zed Updater with UTF-8 support and structured logging
 """
 
 import sys
+import json
+import time
 import logging
 import logging.handlers
 from pathlib import Path
@@ -59,12 +61,64 @@ class UTF8Formatter(logging.Formatter):
         return formatter.format(record)
 
 
+class JSONFormatter(logging.Formatter):
+    """One JSON object per line for log collectors
+
+    Fields passed with extra=... are included next to time, level,
+    logger and message.
+    """
+
+    RECORD_ATTRS = frozenset(vars(logging.makeLogRecord({}))) | {'message', 'asctime'}
+
+    def format(self, record: logging.LogRecord) -> str:
+        entry = {
+            'time': datetime.fromtimestamp(record.created).isoformat(timespec='milliseconds'),
+            'level': record.levelname,
+            'logger': record.name,
+            'message': record.getMessage(),
+        }
+        for key, value in vars(record).items():
+            if key not in self.RECORD_ATTRS:
+                entry[key] = value
+        if record.exc_info:
+            entry['exception'] = self.formatException(record.exc_info)
+        return json.dumps(entry, ensure_ascii=False, default=str)
+
+
+class RetainingFileHandler(logging.handlers.RotatingFileHandler):
+    """Size-rotated log file whose rotated copies expire after max_age_days"""
+
+    def __init__(self, filename: str, max_age_days: int = 0, **kwargs):
+        super().__init__(filename, **kwargs)
+        self.max_age_days = max_age_days
+        self.remove_expired()
+
+    def doRollover(self) -> None:
+        super().doRollover()
+        self.remove_expired()
+
+    def remove_expired(self) -> None:
+        """Delete rotated files last written more than max_age_days ago"""
+        if self.max_age_days <= 0:
+            return
+        cutoff = time.time() - self.max_age_days * 86400
+        current = Path(self.baseFilename)
+        for path in current.parent.glob(f"{current.name}.*"):
+            try:
+                if path.stat().st_mtime < cutoff:
+                    path.unlink()
+            except OSError:
+                pass
+
+
 def setup_logging(
     level: str = 'INFO',
     log_file: Optional[str] = None,
     max_bytes: int = 10 * 1024 * 1024,  # 10MB
     backup_count: int = 5,
-    use_colors: bool = True
+    use_colors: bool = True,
+    log_format: str = 'text',
+    max_age_days: int = 0
 ) -> logging.Logger:
     """
     Setup logging configuration
@@ -75,6 +129,8 @@ def setup_logging(
         max_bytes: Maximum log file size
         backup_count: Number of backup files to keep
         use_colors: Whether to use colored output
+        log_format: "text" or "json"
+        max_age_days: Delete rotated files older than this, 0 to keep them
 
     Returns:
         Root logger instance
@@ -83,13 +139,15 @@ def setup_logging(
     root_logger = logging.getLogger()
     for handler in root_logger.handlers[:]:
         root_logger.removeHandler(handler)
+        handler.close()
 
     # Set level
     numeric_level = getattr(logging, level.upper(), logging.INFO)
     root_logger.setLevel(numeric_level)
 
     # Create formatter
-    formatter = UTF8Formatter(use_colors=use_colors)
+    json_output = log_format == 'json'
+    formatter = JSONFormatter() if json_output else UTF8Formatter(use_colors=use_colors)
 
     # Console handler
     console_handler = logging.StreamHandler(sys.stdout)
@@ -102,9 +160,10 @@ def setup_logging(
         log_path = Path(log_file)
         log_path.parent.mkdir(parents=True, exist_ok=True)
 
-        file_formatter = UTF8Formatter(use_colors=False)  # No colors in file
-        file_handler = logging.handlers.RotatingFileHandler(
-            log_path,
+        file_formatter = JSONFormatter() if json_output else UTF8Formatter(use_colors=False)  # No colors in file
+        file_handler = RetainingFileHandler(
+            str(log_path),
+            max_age_days=max_age_days,
             maxBytes=max_bytes,
             backupCount=backup_count,
             encoding='utf-8'
@@ -0,0 +1,181 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Data directory layout for Zed Updater
+
+Everything the updater writes lives under one platform-specific root:
+
+    <data root>/
+        config.json       configuration
+        history.json      install/rollback history
+        install_journal.json  installs in progress, for crash recovery
+        mirror_status.json    outcome of the last backup mirror run
+        backup_stats.json     backups skipped as duplicates
+        release_cache.json    last GitHub responses and their ETags
+        downloads/        downloaded and staged updates
+        backups/          backups of replaced Zed executables
+        crash_logs/       collected Zed crash logs
+        logs/             updater logs
+
+The root is %LOCALAPPDATA%\\ZedUpdater on Windows,
+~/Library/Application Support/ZedUpdater on macOS and
+$XDG_DATA_HOME/zed-updater (~/.local/share/zed-updater) elsewhere.
+ZED_UPDATER_HOME overrides it.
+"""
+
+import os
+import re
+import glob
+import shutil
+import string
+import platform
+from datetime import datetime
+from pathlib import Path
+from typing import Dict, Optional, Set
+
+
+DATA_LAYOUT = {
+    'config': 'config.json',
+    'history': 'history.json',
+    'journal': 'install_journal.json',
+    'mirror_status': 'mirror_status.json',
+    'backup_stats': 'backup_stats.json',
+    'release_cache': 'release_cache.json',
+    'metrics': 'metrics.json',
+    'audit': 'audit.json',
+    'downloads': 'downloads',
+    'backups': 'backups',
+    'crash_logs': 'crash_logs',
+    'logs': 'logs',
+}
+
+
+def get_data_root() -> Path:
+    """Get the platform-appropriate data root"""
+    override = os.environ.get('ZED_UPDATER_HOME')
+    if override:
+        return Path(override).expanduser()
+
+    home = Path.home()
+    system = platform.system()
+    if system == "Windows":
+        return Path(os.environ.get('LOCALAPPDATA', home / 'AppData' / 'Local')) / 'ZedUpdater'
+    if system == "Darwin":
+        return home / 'Library' / 'Application Support' / 'ZedUpdater'
+    return Path(os.environ.get('XDG_DATA_HOME', home / '.local' / 'share')) / 'zed-updater'
+
+
+def get_data_path(name: str) -> Path:
+    """Get a path from DATA_LAYOUT below the data root"""
+    return get_data_root() / DATA_LAYOUT[name]
+
+
+def default_install_path() -> Path:
+    """Where Zed is usually installed on this platform, for first-run configs
+
+    An existing copy at the official install location, on the PATH or at
+    the old default D:\\Zed.exe wins; otherwise the official location,
+    even though nothing is there yet.
+    """
+    home = Path.home()
+    system = platform.system()
+    if system == "Windows":
+        official = Path(os.environ.get('LOCALAPPDATA', home / 'AppData' / 'Local')) / 'Programs' / 'Zed' / 'Zed.exe'
+        legacy = [Path(r"D:\Zed.exe")]
+    elif system == "Darwin":
+        official = Path('/Applications/Zed.app/Contents/MacOS/zed')
+        legacy = [home / 'Applications' / 'Zed.app' / 'Contents' / 'MacOS' / 'zed']
+    else:
+        # Location used by the zed.dev install script
+        official = home / '.local' / 'zed.app' / 'bin' / 'zed'
+        legacy = []
+
+    on_path = shutil.which('zed')
+    for candidate in [official] + ([Path(on_path)] if on_path else []) + legacy:
+        if candidate.is_file():
+            return candidate
+    return official
+
+
+_UNSAFE_CHARS = re.compile(r'[\\/:*?"<>|]')
+
+
+def render_path(template: str, variables: Dict[str, Optional[str]]) -> Path:
+    """Expand {name} variables in a configured path
+
+    {data} is the data root and {home} the user's home directory; ~ and
+    environment variables are expanded as well. Values are sanitised so
+    that e.g. a version can never add path components.
+
+    A variable whose value is None is unknown. The path is then cut before
+    the first component that uses it, which gives the directory holding
+    every possible rendering. That directory may hold unrelated files, so
+    use glob_path to find the renderings themselves.
+
+    Raises:
+        ValueError: If the template uses an unknown variable
+    """
+    values, unknown = _template_values(variables)
+    rendered_parts = []
+    for part in _template_parts(template, set(values) | unknown):
+        fields = {field for _, field, _, _ in string.Formatter().parse(part) if field}
+        if fields & unknown:
+            break
+        rendered_parts.append(part.format_map(values))
+
+    return Path(*rendered_parts) if rendered_parts else Path('.')
+
+
+def glob_path(template: str, variables: Dict[str, Optional[str]]) -> str:
+    """Glob pattern matching every rendering of a configured path
+
+    Like render_path, but a variable whose value is None matches any text
+    within its path component rather than cutting the path short, so the
+    pattern never matches a parent of the rendered directories.
+
+    Raises:
+        ValueError: If the template uses an unknown variable
+    """
+    values, unknown = _template_values(variables)
+    pattern_parts = []
+    for part in _template_parts(template, set(values) | unknown):
+        pattern = ''
+        for literal, field, _, _ in string.Formatter().parse(part):
+            pattern += glob.escape(literal)
+            if field:
+                pattern += '*' if field in unknown else glob.escape(values[field])
+        pattern_parts.append(pattern)
+
+    return os.path.join(*pattern_parts) if pattern_parts else '.'
+
+
+def _template_values(variables: Dict[str, Optional[str]]):
+    """Sanitised values and the names of unknown variables for a path template"""
+    values = {'data': str(get_data_root()), 'home': str(Path.home())}
+    unknown = set()
+    for name, value in variables.items():
+        if value is None:
+            unknown.add(name)
+        else:
+            values[name] = _UNSAFE_CHARS.sub('_', str(value))
+    return values, unknown
+
+
+def _template_parts(template: str, names: Set[str]):
+    """Path components of a template after ~ and environment variables are expanded
+
+    Raises:
+        ValueError: If a component uses a variable not in names
+    """
+    parts = Path(os.path.expanduser(os.path.expandvars(template))).parts
+    for part in parts:
+        fields = {field for _, field, _, _ in string.Formatter().parse(part) if field}
+        missing = fields - names
+        if missing:
+            raise ValueError(f"Unknown path variable: {{{missing.pop()}}}")
+    return parts
+
+
+def today() -> str:
+    """Date as used by the {date} path variable"""
+    return datetime.now().strftime('%Y-%m-%d')
@@ -0,0 +1,73 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Transfer rate and ETA tracking for downloads
+"""
+
+import time
+from collections import deque
+from typing import Optional, Callable
+
+
+class TransferRateTracker:
+    """Rolling-average transfer rate over a short time window"""
+
+    def __init__(self, window_seconds: float = 5.0, clock: Callable[[], float] = time.monotonic):
+        self.window_seconds = window_seconds
+        self._clock = clock
+        self._samples = deque()  # (timestamp, total bytes transferred)
+
+    def reset(self) -> None:
+        """Forget all samples, e.g. after a pause"""
+        self._samples.clear()
+
+    def update(self, transferred: int) -> None:
+        """Record the total number of bytes transferred so far"""
+        now = self._clock()
+        self._samples.append((now, transferred))
+
+        # Keep one sample older than the window so the rate spans it fully
+        while len(self._samples) > 2 and now - self._samples[1][0] >= self.window_seconds:
+            self._samples.popleft()
+
+    @property
+    def rate(self) -> float:
+        """Average bytes per second across the window"""
+        if len(self._samples) < 2:
+            return 0.0
+
+        start_time, start_bytes = self._samples[0]
+        end_time, end_bytes = self._samples[-1]
+        elapsed = end_time - start_time
+        if elapsed <= 0:
+            return 0.0
+        return max(end_bytes - start_bytes, 0) / elapsed
+
+    def eta(self, total: int) -> Optional[float]:
+        """Estimated seconds remaining, None if unknown"""
+        rate = self.rate
+        if not total or rate <= 0 or not self._samples:
+            return None
+        return max(total - self._samples[-1][1], 0) / rate
+
+
+def format_size(num_bytes: float) -> str:
+    """Format a byte count for display"""
+    for unit in ('B', 'KB', 'MB', 'GB'):
+        if abs(num_bytes) < 1024 or unit == 'GB':
+            return f"{num_bytes:.1f} {unit}" if unit != 'B' else f"{int(num_bytes)} B"
+        num_bytes /= 1024
+    return f"{num_bytes:.1f} GB"
+
+
+def format_duration(seconds: Optional[float]) -> str:
+    """Format a duration in seconds as [H:]MM:SS"""
+    if seconds is None:
+        return "--:--"
+
+    seconds = int(round(seconds))
+    hours, remainder = divmod(seconds, 3600)
+    minutes, secs = divmod(remainder, 60)
+    if hours:
+        return f"{hours}:{minutes:02d}:{secs:02d}"
+    return f"{minutes:02d}:{secs:02d}"
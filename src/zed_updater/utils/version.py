@@ -0,0 +1,71 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Version parsing and comparison
+"""
+
+import re
+from typing import Optional, Tuple, Union
+
+_DATE = re.compile(r'(\d{4})-(\d{2})-(\d{2})')
+_SEMVER = re.compile(r'(\d+(?:\.\d+)*)(?:-([0-9A-Za-z]+(?:\.[0-9A-Za-z]+)*))?')
+
+Version = Tuple[Tuple[int, ...], Tuple[Union[int, str], ...]]
+
+
+def parse_version(text: str) -> Optional[Version]:
+    """Parse a version string into a comparable (release, prerelease) pair
+
+    Accepts tags such as "v0.150.1", "0.151.0-pre.2", Windows file
+    versions ("0.150.1.0") and date-based versions ("2024-01-15"). A
+    leading "v", text around the version and +build metadata are
+    ignored. Returns None if no version is found.
+    """
+    date = _DATE.search(text or "")
+    if date:
+        return tuple(int(part) for part in date.groups()), ()
+
+    match = _SEMVER.search(text or "")
+    if not match:
+        return None
+    release = tuple(int(part) for part in match.group(1).split('.'))
+    prerelease = tuple(int(part) if part.isdigit() else part
+                       for part in (match.group(2) or "").split('.') if part)
+    return release, prerelease
+
+
+def _compare_prerelease(a: tuple, b: tuple) -> int:
+    # A release sorts after all of its prereleases
+    if not a or not b:
+        return (not a) - (not b)
+    for x, y in zip(a, b):
+        if x == y:
+            continue
+        # Numeric identifiers sort before alphanumeric ones
+        if isinstance(x, int) != isinstance(y, int):
+            return -1 if isinstance(x, int) else 1
+        return -1 if x < y else 1
+    return (len(a) > len(b)) - (len(a) < len(b))
+
+
+def compare_versions(a: str, b: str) -> Optional[int]:
+    """Compare two version strings following semver precedence
+
+    Missing trailing components count as zero, so "0.150.1" equals
+    "0.150.1.0".
+
+    Returns:
+        -1, 0 or 1 as a is older than, equal to or newer than b; None if
+        either cannot be parsed
+    """
+    parsed_a, parsed_b = parse_version(a), parse_version(b)
+    if parsed_a is None or parsed_b is None:
+        return None
+
+    release_a, release_b = parsed_a[0], parsed_b[0]
+    width = max(len(release_a), len(release_b))
+    release_a += (0,) * (width - len(release_a))
+    release_b += (0,) * (width - len(release_b))
+    if release_a != release_b:
+        return -1 if release_a < release_b else 1
+    return _compare_prerelease(parsed_a[1], parsed_b[1])
@@ -0,0 +1,78 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+发布压缩包解压测试
+"""
+
+import io
+import os
+import sys
+import tarfile
+import tempfile
+import unittest
+import zipfile
+from pathlib import Path
+
+# 添加源码目录到路径
+sys.path.insert(0, str(Path(__file__).parent.parent / 'src'))
+
+from zed_updater.utils.archive import archive_suffix, extract_archive, find_binary
+
+
+class TestArchive(unittest.TestCase):
+    """测试压缩包解压与可执行文件查找"""
+
+    def setUp(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.dir = Path(self.temp_dir.name)
+
+    def tearDown(self):
+        self.temp_dir.cleanup()
+
+    def test_archive_suffix(self):
+        self.assertEqual(archive_suffix('zed-windows.ZIP'), '.zip')
+        self.assertEqual(archive_suffix('zed-linux.tar.gz'), '.tar.gz')
+        self.assertIsNone(archive_suffix('Zed.exe'))
+
+    def test_zip_preserves_permissions(self):
+        """zip 中的 Unix 权限应被保留"""
+        archive = self.dir / 'zed.zip'
+        with zipfile.ZipFile(archive, 'w') as zf:
+            info = zipfile.ZipInfo('zed-1.0/bin/zed')
+            info.external_attr = 0o755 << 16
+            zf.writestr(info, b'\x7fELF')
+
+        out = extract_archive(archive, self.dir / 'out')
+        binary = out / 'zed-1.0' / 'bin' / 'zed'
+        self.assertEqual(binary.read_bytes(), b'\x7fELF')
+        if os.name != 'nt':
+            self.assertEqual(binary.stat().st_mode & 0o777, 0o755)
+
+    def test_tar_path_traversal_rejected(self):
+        """拒绝写到解压目录之外的成员"""
+        archive = self.dir / 'evil.tar.gz'
+        with tarfile.open(archive, 'w:gz') as tf:
+            info = tarfile.TarInfo('../escape')
+            info.size = 2
+            tf.addfile(info, io.BytesIO(b'MZ'))
+
+        with self.assertRaises(ValueError):
+            extract_archive(archive, self.dir / 'out')
+        self.assertFalse((self.dir / 'escape').exists())
+
+    def test_find_binary(self):
+        """按名称查找时优先浅层文件，显式路径优先"""
+        root = self.dir / 'out'
+        (root / 'a' / 'b').mkdir(parents=True)
+        (root / 'a' / 'Zed.exe').write_bytes(b'MZ1')
+        (root / 'a' / 'b' / 'zed.exe').write_bytes(b'MZ2')
+
+        self.assertEqual(find_binary(root, names=('Zed.exe',)), root / 'a' / 'Zed.exe')
+        self.assertEqual(find_binary(root, 'a/b/zed.exe').resolve(), (root / 'a' / 'b' / 'zed.exe').resolve())
+        self.assertIsNone(find_binary(root, 'missing.exe'))
+        with self.assertRaises(ValueError):
+            find_binary(root, '../outside')
+
+
+if __name__ == '__main__':
+    unittest.main()
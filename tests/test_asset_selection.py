@@ -0,0 +1,90 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+发布文件选择测试
+"""
+
+import sys
+import unittest
+from pathlib import Path
+
+# 添加源码目录到路径
+sys.path.insert(0, str(Path(__file__).parent.parent / 'src'))
+
+from zed_updater.services.github_api import GitHubAPI
+
+
+def release(*names):
+    return {
+        'tag_name': 'v0.151.0',
+        'published_at': '2024-06-01T00:00:00Z',
+        'assets': [{'name': name, 'browser_download_url': f"https://example.com/{name}", 'size': 1}
+                   for name in names],
+    }
+
+
+class TestAssetSelection(unittest.TestCase):
+    """按平台和架构选择发布文件"""
+
+    ASSETS = ('zed-linux-x86_64.tar.gz', 'zed-macos-aarch64.dmg', 'zed-macos-aarch64.zip',
+              'zed-windows-aarch64.exe', 'zed-windows-x86_64.exe', 'zed.exe.sha256')
+
+    def setUp(self):
+        self.api = GitHubAPI()
+
+    def selected(self, *names):
+        return self.api._parse_release(release(*names)).download_url.rsplit('/', 1)[-1]
+
+    def test_platform_and_arch_matrix(self):
+        """每个平台和架构选中对应文件"""
+        for system, arch, expected in (('windows', 'x86_64', 'zed-windows-x86_64.exe'),
+                                       ('windows', 'aarch64', 'zed-windows-aarch64.exe'),
+                                       ('linux', 'x86_64', 'zed-linux-x86_64.tar.gz'),
+                                       ('macos', 'aarch64', 'zed-macos-aarch64.zip')):
+            self.api.set_platform(system, arch)
+            self.assertEqual(self.selected(*self.ASSETS), expected, (system, arch))
+
+    def test_suffix_without_platform_name(self):
+        """文件名没有平台时按扩展名判断"""
+        self.api.set_platform('windows', 'x86_64')
+        self.assertEqual(self.selected('Zed.dmg', 'Zed.exe'), 'Zed.exe')
+
+    def test_other_arch_as_fallback(self):
+        """没有匹配架构时使用其他架构"""
+        self.api.set_platform('windows', 'aarch64')
+        self.assertEqual(self.selected('zed-windows-x86_64.exe'), 'zed-windows-x86_64.exe')
+
+    def test_other_platform_never_selected(self):
+        """不会选中其他平台的文件"""
+        self.api.set_platform('linux', 'x86_64')
+        self.assertEqual(self.selected('zed-windows-x86_64.exe', 'Zed.dmg'), '')
+
+    def test_uninstallable_formats_never_selected(self):
+        """无法安装的格式 (dmg、tar.xz、AppImage) 不会被选中"""
+        self.api.set_platform('macos', 'aarch64')
+        self.assertEqual(self.selected('zed-macos-aarch64.dmg'), '')
+        self.api.set_platform('linux', 'x86_64')
+        self.assertEqual(self.selected('zed-linux-x86_64.tar.xz', 'zed-linux-x86_64.AppImage'), '')
+        self.api.set_asset_pattern(r'\.tar\.xz$')
+        self.assertEqual(self.selected('zed-linux-x86_64.tar.xz'), '')
+
+    def test_asset_pattern(self):
+        """正则表达式限定候选文件，优先于平台规则"""
+        self.api.set_platform('windows', 'x86_64')
+        self.api.set_asset_pattern(r'portable\.zip$')
+        self.assertEqual(self.selected('zed-windows-x86_64.exe', 'zed-linux-portable.zip'),
+                         'zed-linux-portable.zip')
+        self.api.set_asset_pattern(r'nothing')
+        self.assertEqual(self.selected('zed-windows-x86_64.exe'), '')
+
+    def test_language_among_best_matches(self):
+        """在最匹配的文件中按语言选择"""
+        self.api.set_platform('windows', 'x86_64')
+        self.api.set_language_preference(['zh-CN'])
+        self.assertEqual(self.selected('zed-windows-x86_64-en.exe', 'zed-windows-x86_64-zh_CN.exe',
+                                       'zed-linux-x86_64-zh_CN.tar.gz'),
+                         'zed-windows-x86_64-zh_CN.exe')
+
+
+if __name__ == '__main__':
+    unittest.main()
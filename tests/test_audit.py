@@ -0,0 +1,70 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+审计日志测试
+"""
+
+import os
+import sys
+import tempfile
+import unittest
+from unittest import mock
+from pathlib import Path
+
+# 添加源码目录到路径
+sys.path.insert(0, str(Path(__file__).parent.parent / 'src'))
+
+from zed_updater.core.audit import AuditLog, AuditEntry
+from zed_updater.core.config import ConfigManager, REDACTED
+
+
+class TestAuditLog(unittest.TestCase):
+    """AuditLog 和配置修改记录测试"""
+
+    def setUp(self):
+        self._tmp = tempfile.TemporaryDirectory()
+        self._old_home = os.environ.get('ZED_UPDATER_HOME')
+        os.environ['ZED_UPDATER_HOME'] = self._tmp.name
+        self.audit = AuditLog(Path(self._tmp.name) / 'audit.json')
+
+    def tearDown(self):
+        if self._old_home is None:
+            os.environ.pop('ZED_UPDATER_HOME', None)
+        else:
+            os.environ['ZED_UPDATER_HOME'] = self._old_home
+        self._tmp.cleanup()
+
+    def test_newest_first_and_filter(self):
+        self.audit.record(AuditEntry(action='install', success=True, details={'version': '0.150.0'}))
+        self.audit.record(AuditEntry(action='delete_backup', success=False))
+        self.audit.record(AuditEntry(action='install', success=False, details={'version': '0.151.0'}))
+
+        entries = self.audit.get_entries()
+        self.assertEqual([entry.action for entry in entries], ['install', 'delete_backup', 'install'])
+        self.assertEqual(entries[0].details['version'], '0.151.0')
+        self.assertTrue(entries[0].user)
+
+        installs = self.audit.get_entries(limit=1, action='install')
+        self.assertEqual(len(installs), 1)
+        self.assertFalse(installs[0].success)
+
+    def test_oldest_dropped(self):
+        with mock.patch.object(AuditLog, 'MAX_ENTRIES', 3):
+            for index in range(5):
+                self.audit.record(AuditEntry(action='install', success=True, details={'index': index}))
+        self.assertEqual([entry.details['index'] for entry in self.audit.get_entries()], [4, 3, 2])
+
+    def test_config_changes_are_recorded(self):
+        config = ConfigManager(str(Path(self._tmp.name) / 'config.json'))
+        config.credentials._keyring = None
+        config.update({'backup_count': 5, 'github_token': 'secret'})
+        config.set('backup_count', 5)  # Unchanged, not recorded
+
+        entries = config.audit.get_entries(action='config_change')
+        self.assertEqual(len(entries), 1)
+        self.assertEqual(entries[0].details['backup_count'], {'old': 3, 'new': 5})
+        self.assertEqual(entries[0].details['github_token'], {'old': '', 'new': REDACTED})
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,91 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+配置文件版本升级测试
+"""
+
+import json
+import os
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+from unittest import mock
+
+# 添加源码目录到路径
+sys.path.insert(0, str(Path(__file__).parent.parent / 'src'))
+
+from zed_updater.core import config as config_module
+from zed_updater.core.config import CONFIG_VERSION, ConfigManager, migrate_config_data
+
+
+class TestConfigMigration(unittest.TestCase):
+    """migrate_config_data 和 ConfigManager 加载旧配置测试"""
+
+    def setUp(self):
+        self._tmp = tempfile.TemporaryDirectory()
+        self.config_file = Path(self._tmp.name) / 'config.json'
+        self._old_home = os.environ.get('ZED_UPDATER_HOME')
+        os.environ['ZED_UPDATER_HOME'] = self._tmp.name
+
+    def tearDown(self):
+        if self._old_home is None:
+            os.environ.pop('ZED_UPDATER_HOME', None)
+        else:
+            os.environ['ZED_UPDATER_HOME'] = self._old_home
+        self._tmp.cleanup()
+
+    def write(self, data):
+        self.config_file.write_text(json.dumps(data), encoding='utf-8')
+
+    def test_unversioned_file_is_version_1(self):
+        """没有版本号的配置视为版本 1 并升级到当前版本"""
+        data, stored_version = migrate_config_data({'github_repo': 'a/b'})
+        self.assertEqual(stored_version, 1)
+        self.assertEqual(data['config_version'], CONFIG_VERSION)
+        self.assertEqual(data['github_repo'], 'a/b')
+
+    def test_old_windows_default_replaced_elsewhere(self):
+        """非 Windows 系统上替换旧的 D:\\Zed.exe 默认值"""
+        with mock.patch.object(config_module.platform, 'system', return_value='Linux'):
+            data, _ = migrate_config_data({'zed_install_path': r"D:\Zed.exe"})
+        self.assertNotEqual(data['zed_install_path'], r"D:\Zed.exe")
+        with mock.patch.object(config_module.platform, 'system', return_value='Windows'):
+            data, _ = migrate_config_data({'zed_install_path': r"D:\Zed.exe"})
+        self.assertEqual(data['zed_install_path'], r"D:\Zed.exe")
+
+    def test_migrations_run_in_order(self):
+        """依次执行每个版本的升级步骤"""
+        steps = {
+            1: lambda data: dict(data, steps=data.get('steps', '') + '1'),
+            2: lambda data: dict(data, steps=data['steps'] + '2'),
+        }
+        with mock.patch.object(config_module, 'MIGRATIONS', steps), \
+                mock.patch.object(config_module, 'CONFIG_VERSION', 3):
+            data, _ = migrate_config_data({})
+        self.assertEqual(data['steps'], '12')
+        self.assertEqual(data['config_version'], 3)
+
+    def test_upgrade_rewrites_file_and_keeps_backup(self):
+        """升级后重写配置文件，并保留原文件"""
+        self.write({'github_repo': 'a/b'})
+        manager = ConfigManager(str(self.config_file))
+        self.assertEqual(manager.get('github_repo'), 'a/b')
+        stored = json.loads(self.config_file.read_text(encoding='utf-8'))
+        self.assertEqual(stored['config_version'], CONFIG_VERSION)
+        backup = self.config_file.with_name('config.json.v1.bak')
+        self.assertEqual(json.loads(backup.read_text(encoding='utf-8')), {'github_repo': 'a/b'})
+
+    def test_unknown_keys_survive_saving(self):
+        """未知的配置项保存后仍然保留"""
+        self.write({'config_version': CONFIG_VERSION + 1, 'future_setting': 42})
+        manager = ConfigManager(str(self.config_file))
+        manager.set('backup_count', 5)
+        stored = json.loads(self.config_file.read_text(encoding='utf-8'))
+        self.assertEqual(stored['future_setting'], 42)
+        self.assertEqual(stored['config_version'], CONFIG_VERSION + 1)
+        self.assertEqual(stored['backup_count'], 5)
+
+
+if __name__ == '__main__':
+    unittest.main()
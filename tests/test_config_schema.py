@@ -0,0 +1,109 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+配置项说明 (config schema) 测试
+"""
+
+import os
+import sys
+import json
+import tempfile
+import unittest
+from dataclasses import fields
+from pathlib import Path
+
+# 添加源码目录到路径
+sys.path.insert(0, str(Path(__file__).parent.parent / 'src'))
+
+from zed_updater.core.config import ConfigData, ConfigManager
+from zed_updater.core.config_schema import FIELD_INFO, SCHEMA_LANGUAGES, get_config_schema, check_value
+
+
+class TestConfigSchema(unittest.TestCase):
+    """get_config_schema 和 check_value 测试"""
+
+    def test_every_field_described(self):
+        """每个配置项在所有语言下都有说明"""
+        names = {config_field.name for config_field in fields(ConfigData)}
+        self.assertEqual(names, set(FIELD_INFO))
+        for name, info in FIELD_INFO.items():
+            for language in SCHEMA_LANGUAGES:
+                self.assertTrue(info['description'].get(language), f"{name} ({language})")
+
+    def test_defaults_satisfy_constraints(self):
+        """默认值符合取值限制"""
+        for entry in get_config_schema():
+            self.assertIsNone(check_value(entry['name'], entry['default']), entry['name'])
+
+    def test_entry_shape(self):
+        """条目包含类型、列表元素类型和令牌标记"""
+        schema = {entry['name']: entry for entry in get_config_schema('en')}
+        self.assertEqual(schema['backup_count']['type'], 'integer')
+        self.assertEqual(schema['fallback_repos']['items'], 'string')
+        self.assertTrue(schema['github_token']['secret'])
+        self.assertEqual(schema['backup_count']['description'], "Number of backups to keep")
+
+    def test_check_value(self):
+        """违反取值限制时返回原因"""
+        self.assertIsNotNone(check_value('release_channel', 'beta'))
+        self.assertIsNotNone(check_value('backup_count', 0))
+        self.assertIsNotNone(check_value('check_time', '25:00'))
+        self.assertIsNone(check_value('check_time', '03:30'))
+
+    def test_check_launch_profiles(self):
+        """启动配置只接受已知的设置和类型"""
+        self.assertIsNone(check_value('zed_launch_profiles', {
+            'preview': {'path': '/opt/zed-preview/zed', 'user_data_dir': '/tmp/zed-preview', 'args': ['--new']},
+        }))
+        self.assertIsNotNone(check_value('zed_launch_profiles', {'preview': '/opt/zed-preview/zed'}))
+        self.assertIsNotNone(check_value('zed_launch_profiles', {'preview': {'binary': '/opt/zed'}}))
+        self.assertIsNotNone(check_value('zed_launch_profiles', {'preview': {'args': '--new'}}))
+        schema = {entry['name']: entry for entry in get_config_schema()}
+        self.assertEqual(schema['zed_launch_profiles']['type'], 'object')
+
+
+
+class TestDirectorySettings(unittest.TestCase):
+    """目录设置在所有写入途径都会检查"""
+
+    def setUp(self):
+        self._tmp = tempfile.TemporaryDirectory()
+        self.root = Path(self._tmp.name)
+        self._old_home = os.environ.get('ZED_UPDATER_HOME')
+        os.environ['ZED_UPDATER_HOME'] = str(self.root)
+        self.config_file = self.root / 'config.json'
+        self.config = ConfigManager(str(self.config_file))
+
+    def tearDown(self):
+        if self._old_home is None:
+            os.environ.pop('ZED_UPDATER_HOME', None)
+        else:
+            os.environ['ZED_UPDATER_HOME'] = self._old_home
+        self._tmp.cleanup()
+
+    def test_update_rejects_invalid_directory(self):
+        """update 拒绝相对路径，其他设置照常保存"""
+        self.assertFalse(self.config.update({'download_dir': '{version}/dl', 'backup_count': 5}))
+        self.assertEqual(self.config.get('download_dir'), '')
+        self.assertEqual(self.config.get('backup_count'), 5)
+        self.assertFalse(self.config.set('backup_dir', 'backups'))
+
+    def test_import_and_load_use_default(self):
+        """导入和加载时无效的目录改用默认目录"""
+        data = self.config.export_config()
+        data['download_dir'] = 'relative/dir'
+        self.config.import_config(data)
+        self.assertEqual(self.config.get('download_dir'), '')
+
+        self.config_file.write_text(json.dumps(dict(data, backup_dir='~{version}')), encoding='utf-8')
+        self.assertEqual(ConfigManager(str(self.config_file)).get('backup_dir'), '')
+
+    def test_valid_directory_accepted(self):
+        """绝对路径模板可以保存"""
+        template = str(self.root / 'dl' / '{version}')
+        self.assertTrue(self.config.set('download_dir', template))
+        self.assertEqual(self.config.get('download_dir'), template)
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,71 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+更新后崩溃监视测试
+"""
+
+import os
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+from unittest import mock
+
+# 添加源码目录到路径
+sys.path.insert(0, str(Path(__file__).parent.parent / 'src'))
+
+from zed_updater.core.config import ConfigManager
+from zed_updater.core.updater import ZedUpdater
+
+
+class FakeProcess:
+    """wait() 立即返回给定退出码的进程"""
+
+    def __init__(self, exit_code):
+        self.exit_code = exit_code
+
+    def wait(self, timeout=None):
+        return self.exit_code
+
+
+class TestCrashWatchdog(unittest.TestCase):
+    """只有读到非零退出码才算崩溃"""
+
+    def setUp(self):
+        self._tmp = tempfile.TemporaryDirectory()
+        root = Path(self._tmp.name)
+        patch = mock.patch.dict(os.environ, {'ZED_UPDATER_HOME': str(root)})
+        patch.start()
+        self.addCleanup(patch.stop)
+        config = ConfigManager(str(root / 'config.json'))
+        config.update({'crash_watchdog_seconds': 5, 'rollback_on_crash': True})
+        self.updater = ZedUpdater(config)
+        self.crashes = []
+        self.updater.add_crash_callback(lambda message, result: self.crashes.append(message))
+
+    def tearDown(self):
+        self._tmp.cleanup()
+
+    def watch(self, exit_code):
+        with mock.patch.object(self.updater, 'rollback') as rollback, \
+                mock.patch.object(self.updater, '_launch_zed'):
+            self.updater._watch_for_crash(FakeProcess(exit_code), '0.151.0')
+            self.updater.wait_for_crash_watch(5)
+        return rollback
+
+    def test_unknown_exit_code_is_not_a_crash(self):
+        """psutil 读不到退出码 (None) 时不回滚"""
+        rollback = self.watch(None)
+        rollback.assert_not_called()
+        self.assertEqual(self.crashes, [])
+        self.assertEqual([entry.action for entry in self.updater.history.get_entries()], [])
+
+    def test_failing_exit_is_a_crash(self):
+        """非零退出码记为崩溃并回滚"""
+        rollback = self.watch(3)
+        rollback.assert_called_once()
+        self.assertEqual(len(self.crashes), 1)
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,83 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+旧版本数据迁移测试
+"""
+
+import os
+import sys
+import json
+import tempfile
+import unittest
+from pathlib import Path
+from unittest import mock
+
+# 添加源码目录到路径
+sys.path.insert(0, str(Path(__file__).parent.parent / 'src'))
+
+from zed_updater.core.config import ConfigManager
+from zed_updater.core.data_migration import DataMigrator
+
+
+class TestLegacyBackupMigration(unittest.TestCase):
+    """旧备份目录只迁移更新器自己的文件"""
+
+    def setUp(self):
+        self._tmp = tempfile.TemporaryDirectory()
+        self.root = Path(self._tmp.name)
+        patch = mock.patch.dict(os.environ, {'ZED_UPDATER_HOME': str(self.root / 'data'),
+                                             'HOME': str(self.root / 'home')})
+        patch.start()
+        self.addCleanup(patch.stop)
+        self.config = ConfigManager(str(self.root / 'config.json'))
+        # Installed at the "drive root", so the legacy backups/ is shared
+        self.config.set('zed_install_path', str(self.root / 'zed.exe'))
+        self.legacy = self.root / 'backups'
+        self.legacy.mkdir()
+
+    def tearDown(self):
+        self._tmp.cleanup()
+
+    def test_moves_only_backups_and_sidecars(self):
+        """只移动 zed_backup_* 及其校验和与设置文件"""
+        for name in ('zed_backup_1.exe', 'zed_backup_1.exe.sha256', 'zed_backup_1.config.zip', 'photo.jpg'):
+            (self.legacy / name).write_bytes(b'x')
+        (self.legacy / 'empty').mkdir()
+
+        DataMigrator(self.config).migrate()
+
+        target = self.config.get_backup_dir("unknown")
+        self.assertEqual(sorted(path.name for path in target.iterdir()),
+                         ['zed_backup_1.config.zip', 'zed_backup_1.exe', 'zed_backup_1.exe.sha256'])
+        self.assertTrue((self.legacy / 'photo.jpg').exists())
+        self.assertTrue((self.legacy / 'empty').is_dir())
+
+    def test_removes_directory_it_emptied(self):
+        """迁移后变空的旧备份目录被删除"""
+        (self.legacy / 'zed_backup_1.exe').write_bytes(b'x')
+
+        DataMigrator(self.config).migrate()
+
+        self.assertFalse(self.legacy.exists())
+
+    def test_history_paths_compare_components(self):
+        """历史路径按路径层级比较，backups2 不会被改写"""
+        (self.legacy / 'zed_backup_1.exe').write_bytes(b'x')
+        other = str(self.root / 'backups2' / 'zed_backup_2.exe')
+        history_file = self.config.get_history_file()
+        history_file.parent.mkdir(parents=True, exist_ok=True)
+        history_file.write_text(json.dumps([
+            {'backup_path': str(self.legacy / 'zed_backup_1.exe')},
+            {'backup_path': other},
+        ]), encoding='utf-8')
+
+        DataMigrator(self.config).migrate()
+
+        entries = json.loads(history_file.read_text(encoding='utf-8'))
+        self.assertEqual(entries[0]['backup_path'],
+                         str(self.config.get_backup_dir("unknown") / 'zed_backup_1.exe'))
+        self.assertEqual(entries[1]['backup_path'], other)
+
+
+if __name__ == '__main__':
+    unittest.main()
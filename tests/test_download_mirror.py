@@ -0,0 +1,160 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+下载测试：加速镜像的校验和每个下载自己的暂停状态
+"""
+
+import os
+import sys
+import time
+import hashlib
+import tempfile
+import threading
+import unittest
+from pathlib import Path
+from unittest import mock
+
+# 添加源码目录到路径
+sys.path.insert(0, str(Path(__file__).parent.parent / 'src'))
+
+from zed_updater.core.config import ConfigManager
+from zed_updater.core.updater import ZedUpdater
+from zed_updater.services.github_api import GitHubAPI, ReleaseInfo
+
+GOOD = b'MZ' + b'good' * 64
+TAMPERED = b'MZ' + b'evil' * 64
+DIRECT_URL = 'https://github.com/zed-industries/zed/releases/download/v0.151.0/zed.exe'
+
+
+class FakeDownload:
+    """带 iter_content 的下载响应"""
+
+    def __init__(self, content: bytes):
+        self.content = content
+        self.status_code = 200
+        self.headers = {'content-length': str(len(content))}
+
+    def raise_for_status(self):
+        pass
+
+    def iter_content(self, chunk_size=8192):
+        yield self.content
+
+    def close(self):
+        pass
+
+
+class TestMirroredDownloads(unittest.TestCase):
+    """镜像下载的文件必须与直接取得的 SHA-256 一致"""
+
+    def setUp(self):
+        self._tmp = tempfile.TemporaryDirectory()
+        self.root = Path(self._tmp.name)
+        patch = mock.patch.dict(os.environ, {'ZED_UPDATER_HOME': str(self.root)})
+        patch.start()
+        self.addCleanup(patch.stop)
+        config = ConfigManager(str(self.root / 'config.json'))
+        config.set('download_mirror', 'https://ghfast.top/')
+        self.updater = ZedUpdater(config)
+        self.requested = []
+        self.mirror_content = GOOD
+        self.updater.session.get = self.fake_get
+        self.release = ReleaseInfo(
+            version='0.151.0', release_date=None, download_url=DIRECT_URL, description='',
+            size=len(GOOD), sha256=None, assets=[]
+        )
+
+    def tearDown(self):
+        self._tmp.cleanup()
+
+    def fake_get(self, url, **kwargs):
+        self.requested.append(url)
+        return FakeDownload(GOOD if url == DIRECT_URL else self.mirror_content)
+
+    def download(self, checksum):
+        with mock.patch.object(GitHubAPI, 'get_asset_checksum', return_value=checksum):
+            return self.updater.download_update(self.release)
+
+    def test_no_mirror_without_hash(self):
+        """发布没有 SHA-256 时不经过镜像"""
+        path = self.download(None)
+        self.assertEqual(self.requested, [DIRECT_URL])
+        self.assertEqual(path.read_bytes(), GOOD)
+
+    def test_mirror_with_hash(self):
+        """有 SHA-256 时使用镜像"""
+        path = self.download(hashlib.sha256(GOOD).hexdigest())
+        self.assertEqual(self.requested, ['https://ghfast.top/' + DIRECT_URL])
+        self.assertEqual(path.read_bytes(), GOOD)
+
+    def test_tampered_mirror_falls_back(self):
+        """镜像返回的文件与 SHA-256 不符时改为直接下载"""
+        self.mirror_content = TAMPERED
+        path = self.download(hashlib.sha256(GOOD).hexdigest())
+        self.assertEqual(self.requested[-1], DIRECT_URL)
+        self.assertEqual(path.read_bytes(), GOOD)
+
+
+class TestDownloadPause(unittest.TestCase):
+    """暂停状态属于单个下载"""
+
+    def setUp(self):
+        self._tmp = tempfile.TemporaryDirectory()
+        self.root = Path(self._tmp.name)
+        patch = mock.patch.dict(os.environ, {'ZED_UPDATER_HOME': str(self.root)})
+        patch.start()
+        self.addCleanup(patch.stop)
+        self.updater = ZedUpdater(ConfigManager(str(self.root / 'config.json')))
+        self.requested_at = []
+        self.updater.session.get = self.fake_get
+        self.release = ReleaseInfo(
+            version='0.151.0', release_date=None, download_url=DIRECT_URL, description='',
+            size=len(GOOD), sha256=None, assets=[]
+        )
+
+    def tearDown(self):
+        self._tmp.cleanup()
+
+    def fake_get(self, url, **kwargs):
+        self.requested_at.append(time.monotonic())
+        return FakeDownload(GOOD)
+
+    def test_earlier_pause_does_not_block(self):
+        """上一个下载的暂停不影响新的下载"""
+        self.updater.pause_download()
+        self.assertIsNotNone(self.updater.download_update(self.release))
+        self.assertFalse(self.updater.is_download_paused())
+
+    def test_pause_before_transfer_kept(self):
+        """传输开始前请求的暂停不会被取消"""
+        get_download_path = self.updater.get_download_path
+        paused_at = []
+
+        def pause_then_path(release_info):
+            self.updater.pause_download()
+            paused_at.append(time.monotonic())
+            threading.Timer(0.3, self.updater.resume_download).start()
+            return get_download_path(release_info)
+
+        with mock.patch.object(self.updater, 'get_download_path', side_effect=pause_then_path):
+            self.assertIsNotNone(self.updater.download_update(self.release))
+        self.assertGreaterEqual(self.requested_at[0] - paused_at[0], 0.25)
+
+
+class TestAssetDigest(unittest.TestCase):
+    """GitHub 资源的 digest 字段"""
+
+    def test_digest_becomes_release_sha256(self):
+        """所选资源的 sha256 digest 作为发布的 SHA-256"""
+        api = GitHubAPI()
+        api.set_platform('windows', 'x86_64')
+        release = api._parse_release({
+            'tag_name': 'v0.151.0', 'published_at': '2024-06-01T00:00:00Z',
+            'assets': [{'name': 'zed.exe', 'browser_download_url': DIRECT_URL, 'size': 1,
+                        'digest': 'sha256:' + 'A' * 64}],
+        })
+        self.assertEqual(release.sha256, 'a' * 64)
+
+
+if __name__ == '__main__':
+    unittest.main()
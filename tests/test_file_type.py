@@ -0,0 +1,71 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+文件类型魔数校验测试
+"""
+
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+# 添加源码目录到路径
+sys.path.insert(0, str(Path(__file__).parent.parent / 'src'))
+
+from zed_updater.utils.file_type import detect_file_type, validate_file_type, expected_types
+
+
+class TestFileType(unittest.TestCase):
+    """测试文件类型检测"""
+
+    def setUp(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.dir = Path(self.temp_dir.name)
+
+    def tearDown(self):
+        self.temp_dir.cleanup()
+
+    def _write(self, name: str, content: bytes) -> Path:
+        path = self.dir / name
+        path.write_bytes(content)
+        return path
+
+    def test_detect_known_types(self):
+        """识别常见的可执行文件和压缩包"""
+        cases = {
+            'a.exe': (b'MZ\x90\x00' + b'\x00' * 60, 'pe'),
+            'a.bin': (b'\x7fELF\x02\x01\x01', 'elf'),
+            'a.zip': (b'PK\x03\x04\x14\x00', 'zip'),
+            'a.tar.gz': (b'\x1f\x8b\x08\x00', 'gzip'),
+            'a.msi': (b'\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1', 'msi'),
+        }
+        for name, (content, expected) in cases.items():
+            with self.subTest(name=name):
+                self.assertEqual(detect_file_type(self._write(name, content)), expected)
+
+    def test_html_error_page_rejected(self):
+        """保存为 .exe 的 HTML 错误页面应被拒绝"""
+        path = self._write('zed.exe', b'\xef\xbb\xbf\r\n<!DOCTYPE html><html>Login</html>')
+        valid, detected = validate_file_type(path)
+        self.assertFalse(valid)
+        self.assertEqual(detected, 'html')
+
+    def test_extension_mismatch(self):
+        """扩展名与内容不符"""
+        path = self._write('zed.exe', b'PK\x03\x04rest')
+        self.assertEqual(validate_file_type(path), (False, 'zip'))
+        self.assertEqual(validate_file_type(path, 'zed-windows.zip'), (True, 'zip'))
+
+    def test_unknown_extension_accepted(self):
+        """未知扩展名只拒绝网页和空文件"""
+        path = self._write('zed', b'\x00\x01binary')
+        self.assertEqual(validate_file_type(path), (True, 'unknown'))
+        self.assertEqual(validate_file_type(self._write('empty', b'')), (False, 'empty'))
+
+    def test_expected_types_longest_suffix(self):
+        self.assertEqual(expected_types('Zed-Linux.TAR.GZ'), ('gzip',))
+        self.assertIsNone(expected_types('Zed.dmg'))
+
+
+if __name__ == '__main__':
+    unittest.main()
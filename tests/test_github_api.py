@@ -0,0 +1,160 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+GitHub 发布源测试
+"""
+
+import sys
+import time
+import unittest
+from pathlib import Path
+
+# 添加源码目录到路径
+sys.path.insert(0, str(Path(__file__).parent.parent / 'src'))
+
+from zed_updater.services.github_api import GitHubAPI, ReleaseInfo, ReleaseAsset
+from zed_updater.services.gitea_api import GiteaAPI
+from zed_updater.services.gitlab_api import GitLabAPI
+
+
+DRAFT_RELEASE = {
+    'tag_name': 'v0.152.0',
+    'draft': True,
+    'published_at': None,
+    'created_at': '2024-06-02T08:00:00Z',
+    'assets': [{'name': 'zed-linux-x86_64.tar.gz',
+                'browser_download_url': 'https://github.com/zed/zed-linux-x86_64.tar.gz', 'size': 1}],
+}
+
+PUBLISHED_RELEASE = dict(DRAFT_RELEASE, tag_name='v0.151.0', draft=False,
+                         published_at='2024-06-01T00:00:00Z')
+
+
+class TestDraftReleases(unittest.TestCase):
+    """草稿发布没有发布时间"""
+
+    def setUp(self):
+        self.api = GitHubAPI()
+        self.api.set_platform('linux', 'x86_64')
+        self.api._make_request = lambda endpoint, params=None: [DRAFT_RELEASE, PUBLISHED_RELEASE]
+
+    def test_draft_uses_created_at(self):
+        """草稿使用创建时间作为发布日期"""
+        release = self.api._parse_release(DRAFT_RELEASE)
+        self.assertEqual(release.release_date.isoformat(), '2024-06-02T08:00:00+00:00')
+
+    def test_drafts_listed_when_not_ignored(self):
+        """不忽略草稿时列出草稿而不是报错"""
+        self.api.set_release_filter(ignore_drafts=False)
+        self.assertEqual([release.version for release in self.api.get_releases()], ['0.152.0', '0.151.0'])
+
+    def test_drafts_ignored_by_default(self):
+        """默认跳过草稿"""
+        self.assertEqual([release.version for release in self.api.get_releases()], ['0.151.0'])
+
+
+class FakeResponse:
+    status_code = 200
+    headers = {}
+    text = "0" * 64 + "  zed.tar.gz"
+
+    def json(self):
+        return []
+
+    def raise_for_status(self):
+        pass
+
+
+class TestTokenScope(unittest.TestCase):
+    """令牌只发送给实例自身的主机"""
+
+    def setUp(self):
+        self.api = GitLabAPI("group/zed", "https://gitlab.example.com", token="secret")
+        self.sent = []
+        self.api.session.get = lambda url, headers=None, **kwargs: (
+            self.sent.append((url, dict(self.api.session.headers, **(headers or {})))) or FakeResponse())
+
+    def release(self, checksum_url):
+        assets = [ReleaseAsset('zed.tar.gz.sha256', checksum_url, 0, '', api_url=checksum_url)]
+        return ReleaseInfo(version='1.0', release_date=None, download_url='', description='', size=0,
+                           sha256=None, assets=assets)
+
+    def test_not_in_session_headers(self):
+        """令牌不在会话的默认请求头中"""
+        self.assertNotIn('PRIVATE-TOKEN', self.api.session.headers)
+
+    def test_api_requests_authenticated(self):
+        """API 请求带有令牌"""
+        self.api.get_releases()
+        self.assertEqual(self.sent[0][1].get('PRIVATE-TOKEN'), 'secret')
+
+    def test_external_links_unauthenticated(self):
+        """指向其他主机的链接不带令牌"""
+        self.api.get_asset_checksum(self.release('https://cdn.example.net/zed.tar.gz.sha256'), 'zed.tar.gz')
+        self.assertNotIn('PRIVATE-TOKEN', self.sent[0][1])
+        self.api.get_asset_checksum(self.release('https://gitlab.example.com/zed.tar.gz.sha256'), 'zed.tar.gz')
+        self.assertEqual(self.sent[1][1].get('PRIVATE-TOKEN'), 'secret')
+
+    def test_download_request_scoped(self):
+        """外部主机上的下载使用公开地址且不带令牌"""
+        release = self.release('https://cdn.example.net/zed.tar.gz')
+        release.download_url = release.asset_api_url = 'https://cdn.example.net/zed.tar.gz'
+        self.assertEqual(self.api.get_asset_download_request(release), ('https://cdn.example.net/zed.tar.gz', {}))
+
+
+class TestRateLimitedDownloads(unittest.TestCase):
+    """API 请求受限时从公开地址下载"""
+
+    def setUp(self):
+        self.api = GitHubAPI(token="secret")
+        self.release = ReleaseInfo(
+            version='1.0', release_date=None, description='', size=0, sha256=None, assets=[],
+            download_url='https://github.com/TC999/zed-loc/releases/download/v1.0/zed.exe',
+            asset_api_url='https://api.github.com/repos/TC999/zed-loc/releases/assets/1')
+
+    def test_api_url_with_token(self):
+        """未受限时通过 API 地址带令牌下载"""
+        url, headers = self.api.get_asset_download_request(self.release)
+        self.assertEqual(url, self.release.asset_api_url)
+        self.assertEqual(headers['Authorization'], 'Bearer secret')
+
+    def test_public_url_while_limited(self):
+        """受限期间改用 browser_download_url，且不带令牌以便使用镜像"""
+        self.api._rate_limited_until = time.time() + 600
+        self.assertEqual(self.api.get_asset_download_request(self.release), (self.release.download_url, {}))
+
+
+class TestGiteaTokenScope(TestTokenScope):
+    """Gitea 的令牌同样只发送给实例自身的主机"""
+
+    def setUp(self):
+        super().setUp()
+        self.api = GiteaAPI("owner/zed", "https://gitea.example.com", token="secret")
+        self.api.session.get = lambda url, headers=None, **kwargs: (
+            self.sent.append((url, dict(self.api.session.headers, **(headers or {})))) or FakeResponse())
+
+    def test_not_in_session_headers(self):
+        """令牌不在会话的默认请求头中"""
+        self.assertNotIn('Authorization', self.api.session.headers)
+
+    def test_api_requests_authenticated(self):
+        """API 请求带有令牌"""
+        self.api.get_releases()
+        self.assertEqual(self.sent[0][1].get('Authorization'), 'token secret')
+
+    def test_external_links_unauthenticated(self):
+        """指向其他主机的附件不带令牌"""
+        self.api.get_asset_checksum(self.release('https://cdn.example.net/zed.tar.gz.sha256'), 'zed.tar.gz')
+        self.assertNotIn('Authorization', self.sent[0][1])
+        self.api.get_asset_checksum(self.release('https://gitea.example.com/zed.tar.gz.sha256'), 'zed.tar.gz')
+        self.assertEqual(self.sent[1][1].get('Authorization'), 'token secret')
+
+    def test_external_attachment_uses_public_url(self):
+        """外部主机上的附件不经过令牌下载"""
+        release = self.api._normalize_release({'tag_name': 'v1.0', 'created_at': '2024-06-01T00:00:00Z', 'assets': [
+            {'name': 'zed.tar.gz', 'browser_download_url': 'https://cdn.example.net/zed.tar.gz', 'size': 1}]})
+        self.assertEqual(release['assets'][0]['url'], '')
+
+
+if __name__ == '__main__':
+    unittest.main()
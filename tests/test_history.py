@@ -0,0 +1,58 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+更新历史导出测试
+"""
+
+import csv
+import io
+import json
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+# 添加源码目录到路径
+sys.path.insert(0, str(Path(__file__).parent.parent / 'src'))
+
+from zed_updater.core.history import UpdateHistory, HistoryEntry
+
+
+class TestHistoryExport(unittest.TestCase):
+    """UpdateHistory.export 测试"""
+
+    def setUp(self):
+        self._tmp = tempfile.TemporaryDirectory()
+        self.history = UpdateHistory(Path(self._tmp.name) / 'history.json')
+        self.history.record(HistoryEntry('install', True, '0.151.0', '0.150.1',
+                                         timestamp='2024-01-01T10:00:00'))
+        self.history.record(HistoryEntry('rollback', False, None, '0.151.0', "没有可用的备份",
+                                         crash_logs=['a.panic', 'b.panic'],
+                                         timestamp='2024-01-02T10:00:00'))
+
+    def tearDown(self):
+        self._tmp.cleanup()
+
+    def test_csv(self):
+        """CSV 按时间顺序，每条记录一行"""
+        rows = list(csv.DictReader(io.StringIO(self.history.export('csv'))))
+        self.assertEqual([row['action'] for row in rows], ['install', 'rollback'])
+        self.assertEqual(rows[0]['version'], '0.151.0')
+        self.assertEqual(rows[1]['message'], "没有可用的备份")
+        self.assertEqual(rows[1]['crash_logs'], 'a.panic;b.panic')
+
+    def test_json(self):
+        """JSON 保留完整字段"""
+        entries = json.loads(self.history.export('json'))
+        self.assertEqual(len(entries), 2)
+        self.assertEqual(entries[0]['previous_version'], '0.150.1')
+        self.assertEqual(entries[1]['crash_logs'], ['a.panic', 'b.panic'])
+
+    def test_unknown_format(self):
+        """不支持的格式抛出 ValueError"""
+        with self.assertRaises(ValueError):
+            self.history.export('xml')
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,108 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+安装事务日志测试
+"""
+
+import os
+import sys
+import tempfile
+import unittest
+from unittest import mock
+from pathlib import Path
+
+# 添加源码目录到路径
+sys.path.insert(0, str(Path(__file__).parent.parent / 'src'))
+
+from zed_updater.core.config import ConfigManager
+from zed_updater.core.journal import InstallJournal
+from zed_updater.core.updater import ZedUpdater
+
+
+class TestInstallJournal(unittest.TestCase):
+    """InstallJournal 测试"""
+
+    def setUp(self):
+        self._tmp = tempfile.TemporaryDirectory()
+        self.dir = Path(self._tmp.name)
+        self.journal = InstallJournal(self.dir / 'install_journal.json')
+
+    def tearDown(self):
+        self._tmp.cleanup()
+
+    def test_steps_are_persisted(self):
+        """每一步都写入磁盘，新实例可以读到"""
+        entry = self.journal.begin(self.dir / 'zed.exe', self.dir / '.zed.exe.new')
+        self.journal.update(entry, step='staged', staged_sha256='ab' * 32)
+
+        pending = InstallJournal(self.journal.journal_file).pending()
+        self.assertEqual(len(pending), 1)
+        self.assertEqual(pending[0].step, 'staged')
+        self.assertEqual(pending[0].staged_sha256, 'ab' * 32)
+
+    def test_finish_removes_file(self):
+        """所有事务结束后删除日志文件"""
+        first = self.journal.begin(self.dir / 'a' / 'zed.exe', self.dir / 'a' / '.zed.exe.new')
+        second = self.journal.begin(self.dir / 'b' / 'zed.exe', self.dir / 'b' / '.zed.exe.new')
+
+        self.journal.finish(first)
+        self.assertEqual([entry.target for entry in self.journal.pending()], [second.target])
+
+        self.journal.finish(second)
+        self.assertFalse(self.journal.journal_file.exists())
+        self.assertEqual(self.journal.pending(), [])
+
+    def test_begin_replaces_stale_entry(self):
+        """同一目标重新开始时替换旧事务"""
+        target = self.dir / 'zed.exe'
+        entry = self.journal.begin(target, self.dir / '.zed.exe.new')
+        self.journal.update(entry, step='old_moved')
+        self.journal.begin(target, self.dir / '.zed.exe.new')
+
+        pending = self.journal.pending()
+        self.assertEqual(len(pending), 1)
+        self.assertEqual(pending[0].step, 'started')
+
+
+class TestDeferredInstallJournal(unittest.TestCase):
+    """等待 Zed 退出的安装在文件替换前保持事务"""
+
+    def setUp(self):
+        self._tmp = tempfile.TemporaryDirectory()
+        self.dir = Path(self._tmp.name)
+        self._old_home = os.environ.get('ZED_UPDATER_HOME')
+        os.environ['ZED_UPDATER_HOME'] = self._tmp.name
+        self.updater = ZedUpdater(ConfigManager(str(self.dir / 'config.json')))
+        self.updater.get_current_version = lambda path=None: '0.150.0'
+        self.zed_path = self.dir / 'zed'
+        self.zed_path.write_bytes(b'\x7fELF old')
+        self.source_path = self.dir / 'zed-new'
+        self.source_path.write_bytes(b'\x7fELF new')
+
+    def tearDown(self):
+        if self._old_home is None:
+            os.environ.pop('ZED_UPDATER_HOME', None)
+        else:
+            os.environ['ZED_UPDATER_HOME'] = self._old_home
+        self._tmp.cleanup()
+
+    def test_journal_open_until_replaced(self):
+        """延迟的安装直到替换完成才结束事务"""
+        scheduled = []
+        with mock.patch.object(self.updater, '_find_zed_processes', return_value=['zed']), \
+                mock.patch.object(self.updater, '_verify_install_file'), \
+                mock.patch.object(self.updater, '_after_zed_exit', side_effect=scheduled.append):
+            result = self.updater._install_target(self.source_path, self.zed_path,
+                                                  skip_backup=True, defer_until_exit=True)
+
+        self.assertEqual(result.install_method, 'scheduled')
+        self.assertEqual([entry.target for entry in self.updater.journal.pending()], [str(self.zed_path)])
+        self.assertEqual(self.zed_path.read_bytes(), b'\x7fELF old')
+
+        scheduled[0]()
+        self.assertEqual(self.zed_path.read_bytes(), b'\x7fELF new')
+        self.assertEqual(self.updater.journal.pending(), [])
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,88 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+JSON 日志格式和轮转日志保留时间测试
+"""
+
+import json
+import logging
+import os
+import sys
+import tempfile
+import time
+import unittest
+from pathlib import Path
+
+# 添加源码目录到路径
+sys.path.insert(0, str(Path(__file__).parent.parent / 'src'))
+
+from zed_updater.utils.logger import JSONFormatter, RetainingFileHandler
+
+
+class TestJSONFormatter(unittest.TestCase):
+    """JSONFormatter 测试"""
+
+    def _record(self, **kwargs):
+        record = logging.makeLogRecord({
+            'name': 'zed_updater.core.updater', 'levelname': 'INFO', 'levelno': logging.INFO,
+            'msg': "下载完成: %s", 'args': ('zed.exe',),
+        })
+        record.__dict__.update(kwargs)
+        return record
+
+    def test_standard_fields(self):
+        entry = json.loads(JSONFormatter().format(self._record()))
+        self.assertEqual(entry['level'], 'INFO')
+        self.assertEqual(entry['logger'], 'zed_updater.core.updater')
+        self.assertEqual(entry['message'], "下载完成: zed.exe")
+        self.assertIn('time', entry)
+
+    def test_extra_fields_are_included(self):
+        entry = json.loads(JSONFormatter().format(self._record(version='0.151.0', path=Path('/tmp/zed'))))
+        self.assertEqual(entry['version'], '0.151.0')
+        self.assertEqual(entry['path'], str(Path('/tmp/zed')))
+        self.assertNotIn('args', entry)
+
+    def test_exception(self):
+        try:
+            raise ValueError("损坏")
+        except ValueError:
+            record = self._record(exc_info=sys.exc_info())
+        entry = json.loads(JSONFormatter().format(record))
+        self.assertIn("ValueError: 损坏", entry['exception'])
+
+
+class TestRetainingFileHandler(unittest.TestCase):
+    """RetainingFileHandler 测试"""
+
+    def setUp(self):
+        self._tmp = tempfile.TemporaryDirectory()
+        self.log_file = Path(self._tmp.name) / 'zed-updater.log'
+
+    def tearDown(self):
+        self._tmp.cleanup()
+
+    def _rotated(self, index, age_days):
+        path = self.log_file.with_name(f"{self.log_file.name}.{index}")
+        path.write_text("old", encoding='utf-8')
+        stamp = time.time() - age_days * 86400
+        os.utime(path, (stamp, stamp))
+        return path
+
+    def test_removes_expired_rotated_files(self):
+        recent = self._rotated(1, 1)
+        expired = self._rotated(2, 10)
+        handler = RetainingFileHandler(str(self.log_file), max_age_days=7, maxBytes=1024, backupCount=5)
+        handler.close()
+        self.assertTrue(recent.exists())
+        self.assertFalse(expired.exists())
+
+    def test_zero_keeps_everything(self):
+        expired = self._rotated(1, 100)
+        handler = RetainingFileHandler(str(self.log_file), maxBytes=1024, backupCount=5)
+        handler.close()
+        self.assertTrue(expired.exists())
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,69 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Prometheus 指标统计测试
+"""
+
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+# 添加源码目录到路径
+sys.path.insert(0, str(Path(__file__).parent.parent / 'src'))
+
+from zed_updater.core.metrics import UpdateMetrics
+
+
+class TestUpdateMetrics(unittest.TestCase):
+    """UpdateMetrics 测试"""
+
+    def setUp(self):
+        self._tmp = tempfile.TemporaryDirectory()
+        self.metrics_file = Path(self._tmp.name) / 'metrics.json'
+        self.metrics = UpdateMetrics(self.metrics_file)
+
+    def tearDown(self):
+        self._tmp.cleanup()
+
+    def test_empty(self):
+        text = self.metrics.render()
+        self.assertIn('zed_updater_checks_total{result="no_update"} 0', text)
+        self.assertIn('zed_updater_download_duration_seconds_count 0', text)
+        self.assertNotIn('zed_updater_rate_limit_remaining', text)
+
+    def test_counters_survive_restart(self):
+        self.metrics.record_check('no_update')
+        self.metrics.record_check('update_available')
+        self.metrics.record_install('install', True)
+        self.metrics.record_install('rollback', False)
+
+        text = UpdateMetrics(self.metrics_file).render()
+        self.assertIn('zed_updater_checks_total{result="no_update"} 1', text)
+        self.assertIn('zed_updater_checks_total{result="update_available"} 1', text)
+        self.assertIn('zed_updater_installs_total{action="install",result="success"} 1', text)
+        self.assertIn('zed_updater_installs_total{action="rollback",result="failure"} 1', text)
+
+    def test_download_histogram_is_cumulative(self):
+        self.metrics.record_download(True, 1000, 3.0)
+        self.metrics.record_download(True, 500, 100.0)
+        self.metrics.record_download(False, 200)
+
+        text = self.metrics.render()
+        self.assertIn('zed_updater_download_bytes_total 1700', text)
+        self.assertIn('zed_updater_downloads_total{result="failure"} 1', text)
+        self.assertIn('zed_updater_download_duration_seconds_bucket{le="1"} 0', text)
+        self.assertIn('zed_updater_download_duration_seconds_bucket{le="5"} 1', text)
+        self.assertIn('zed_updater_download_duration_seconds_bucket{le="300"} 2', text)
+        self.assertIn('zed_updater_download_duration_seconds_bucket{le="+Inf"} 2', text)
+        self.assertIn('zed_updater_download_duration_seconds_sum 103.0', text)
+
+    def test_rate_limit_gauge(self):
+        text = self.metrics.render([('GitHub:TC999/zed-loc', {'remaining': 42}),
+                                    ('manifest:https://example.com', {'authenticated': False})])
+        self.assertIn('zed_updater_rate_limit_remaining{source="GitHub:TC999/zed-loc"} 42', text)
+        self.assertNotIn('manifest', text)
+
+
+if __name__ == '__main__':
+    unittest.main()
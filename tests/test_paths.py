@@ -0,0 +1,153 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+配置路径模板变量和默认安装路径测试
+"""
+
+import os
+import sys
+import time
+import tempfile
+import unittest
+from pathlib import Path
+from unittest import mock
+
+# 添加源码目录到路径
+sys.path.insert(0, str(Path(__file__).parent.parent / 'src'))
+
+from zed_updater.utils.paths import render_path, glob_path, default_install_path
+from zed_updater.core.config import ConfigManager
+from zed_updater.core.updater import ZedUpdater
+
+
+class TestRenderPath(unittest.TestCase):
+    """render_path 测试"""
+
+    def setUp(self):
+        self._tmp = tempfile.TemporaryDirectory()
+        self.root = Path(self._tmp.name)
+        self._old_home = os.environ.get('ZED_UPDATER_HOME')
+        os.environ['ZED_UPDATER_HOME'] = str(self.root)
+
+    def tearDown(self):
+        if self._old_home is None:
+            os.environ.pop('ZED_UPDATER_HOME', None)
+        else:
+            os.environ['ZED_UPDATER_HOME'] = self._old_home
+        self._tmp.cleanup()
+
+    def test_expands_variables(self):
+        """展开内置变量和传入的变量"""
+        path = render_path('{data}/backups/{channel}/{version}', {'channel': 'stable', 'version': '0.150.1'})
+        self.assertEqual(path, self.root / 'backups' / 'stable' / '0.150.1')
+
+    def test_sanitises_values(self):
+        """变量值不能引入新的路径层级"""
+        path = render_path('{data}/{version}', {'version': '../../v1/2'})
+        self.assertEqual(path.parent, self.root)
+        self.assertNotIn('/', path.name)
+
+    def test_unknown_value_truncates(self):
+        """值为 None 的变量截断到它之前的目录"""
+        path = render_path('{data}/backups/{version}/{date}', {'version': None, 'date': None})
+        self.assertEqual(path, self.root / 'backups')
+
+        path = render_path('{data}/backups/zed-{version}', {'version': None})
+        self.assertEqual(path, self.root / 'backups')
+
+    def test_undefined_variable(self):
+        """未定义的变量抛出 ValueError"""
+        with self.assertRaises(ValueError):
+            render_path('{data}/{nope}', {})
+
+    def test_glob_matches_renderings_only(self):
+        """glob_path 的模式只匹配渲染出的目录，不匹配截断后的上级目录"""
+        pattern = glob_path('{data}/{version}/zed', {'version': None})
+        self.assertEqual(pattern, os.path.join(str(self.root), '*', 'zed'))
+
+        pattern = glob_path('{data}/zed-{version}', {'version': None})
+        self.assertEqual(pattern, os.path.join(str(self.root), 'zed-*'))
+
+
+class TestDirectoryCleanup(unittest.TestCase):
+    """模板目录的清理只涉及渲染出的目录和更新器自己的文件"""
+
+    def setUp(self):
+        self._tmp = tempfile.TemporaryDirectory()
+        self.root = Path(self._tmp.name)
+        self._old_home = os.environ.get('ZED_UPDATER_HOME')
+        os.environ['ZED_UPDATER_HOME'] = str(self.root / 'data')
+        self.config = ConfigManager(str(self.root / 'config.json'))
+        self.config.set('zed_install_path', str(self.root / 'app' / 'zed.exe'))
+
+    def tearDown(self):
+        if self._old_home is None:
+            os.environ.pop('ZED_UPDATER_HOME', None)
+        else:
+            os.environ['ZED_UPDATER_HOME'] = self._old_home
+        self._tmp.cleanup()
+
+    def _old_file(self, path: Path) -> Path:
+        path.parent.mkdir(parents=True, exist_ok=True)
+        path.write_bytes(b'x')
+        old = time.time() - 2 * 24 * 60 * 60
+        os.utime(path, (old, old))
+        return path
+
+    def test_cleanup_keeps_unrelated_files(self):
+        """清理临时文件不删除模板前缀目录里的其他文件"""
+        self.config.set('download_dir', str(self.root / 'dl' / '{version}' / 'zed'))
+        unrelated = self._old_file(self.root / 'dl' / 'notes.txt')
+        nested = self._old_file(self.root / 'dl' / 'photos' / 'zed' / 'img.jpg')
+        download = self._old_file(self.root / 'dl' / '0.150.1' / 'zed' / 'zed_update_0.150.1.exe.part')
+
+        ZedUpdater(self.config).cleanup_temp_files()
+
+        self.assertTrue(unrelated.exists())
+        self.assertTrue(nested.exists())
+        self.assertFalse(download.exists())
+
+    def test_backups_listed_from_rendered_dirs(self):
+        """备份只从渲染出的备份目录列出"""
+        self.config.set('backup_dir', str(self.root / 'bk' / 'v{version}'))
+        stray = self._old_file(self.root / 'bk' / 'other' / 'zed_backup_1.exe')
+        backup = self._old_file(self.root / 'bk' / 'v0.150.0' / 'zed_backup_2.exe')
+
+        self.assertEqual(ZedUpdater(self.config).list_backups(), [backup])
+        self.assertTrue(stray.exists())
+
+
+
+class TestDefaultInstallPath(unittest.TestCase):
+    """default_install_path 测试"""
+
+    def setUp(self):
+        self._tmp = tempfile.TemporaryDirectory()
+        self.home = Path(self._tmp.name)
+        patches = [
+            mock.patch('zed_updater.utils.paths.platform.system', return_value='Linux'),
+            mock.patch('zed_updater.utils.paths.Path.home', return_value=self.home),
+            mock.patch('zed_updater.utils.paths.shutil.which', return_value=None),
+        ]
+        for patch in patches:
+            patch.start()
+            self.addCleanup(patch.stop)
+
+    def tearDown(self):
+        self._tmp.cleanup()
+
+    def test_official_location_when_nothing_installed(self):
+        """没有安装时使用官方安装位置"""
+        self.assertEqual(default_install_path(), self.home / '.local' / 'zed.app' / 'bin' / 'zed')
+
+    def test_prefers_copy_on_path(self):
+        """官方位置不存在时使用 PATH 中的 zed"""
+        binary = self.home / 'bin' / 'zed'
+        binary.parent.mkdir()
+        binary.write_bytes(b'')
+        with mock.patch('zed_updater.utils.paths.shutil.which', return_value=str(binary)):
+            self.assertEqual(default_install_path(), binary)
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,66 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+计划检查间隔测试
+"""
+
+import os
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+# 添加源码目录到路径
+sys.path.insert(0, str(Path(__file__).parent.parent / 'src'))
+
+from zed_updater.core.config import ConfigManager
+from zed_updater.core.scheduler import UpdateScheduler
+from zed_updater.core.updater import ZedUpdater
+
+
+class TestAdaptiveInterval(unittest.TestCase):
+    """检查失败时延长检查间隔"""
+
+    def setUp(self):
+        self._tmp = tempfile.TemporaryDirectory()
+        self._old_home = os.environ.get('ZED_UPDATER_HOME')
+        os.environ['ZED_UPDATER_HOME'] = self._tmp.name
+        self.config = ConfigManager(str(Path(self._tmp.name) / 'config.json'))
+        self.config.update({'check_interval_hours': 6, 'adaptive_check_interval': True})
+        self.updater = ZedUpdater(self.config)
+        self.updater.get_current_version = lambda: '0.150.0'
+        self.updater.get_latest_version_info = lambda: None
+        self.scheduler = UpdateScheduler(self.updater, self.config)
+
+    def tearDown(self):
+        if self._old_home is None:
+            os.environ.pop('ZED_UPDATER_HOME', None)
+        else:
+            os.environ['ZED_UPDATER_HOME'] = self._old_home
+        self._tmp.cleanup()
+
+    def test_unreachable_sources_are_failures(self):
+        """没有发布源响应时检查失败，而不是没有更新"""
+        result = self.updater.check_and_update()
+        self.assertFalse(result.success)
+        self.assertEqual(result.error_code, 'CHECK_FAILED')
+
+    def test_interval_grows_on_repeated_failures(self):
+        """连续获取失败时间隔翻倍，最多 8 倍"""
+        intervals = []
+        for _ in range(4):
+            self.scheduler.force_check_now()
+            intervals.append(self.scheduler.get_effective_interval())
+        self.assertEqual(intervals, [12, 24, 48, 48])
+
+    def test_interval_restored_after_success(self):
+        """恢复后还原设置的间隔"""
+        self.scheduler.force_check_now()
+        self.updater.check_for_updates = lambda: None
+        self.updater.last_check_failed = False
+        self.scheduler.force_check_now()
+        self.assertEqual(self.scheduler.get_effective_interval(), 6)
+
+
+if __name__ == '__main__':
+    unittest.main()
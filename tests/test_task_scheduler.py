@@ -0,0 +1,56 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+cron 计划任务注册测试
+"""
+
+import sys
+import subprocess
+import unittest
+from pathlib import Path
+from unittest import mock
+
+# 添加源码目录到路径
+sys.path.insert(0, str(Path(__file__).parent.parent / 'src'))
+
+from zed_updater.services.task_scheduler import SystemTaskScheduler
+
+
+class TestCronRegistration(unittest.TestCase):
+    """读取 crontab 失败时不能覆盖用户的 crontab"""
+
+    def setUp(self):
+        self.scheduler = SystemTaskScheduler()
+        self.scheduler.is_windows = False
+        self.written = []
+
+    def fake_run(self, returncode, stderr):
+        def run(args, **kwargs):
+            if args == ['crontab', '-l']:
+                return subprocess.CompletedProcess(args, returncode, stdout="", stderr=stderr)
+            self.written.append(kwargs.get('input'))
+            return subprocess.CompletedProcess(args, 0, stdout="", stderr="")
+        return run
+
+    def test_no_crontab_yet(self):
+        """用户还没有 crontab 时新建"""
+        with mock.patch('subprocess.run', side_effect=self.fake_run(1, "no crontab for alice\n")):
+            self.assertTrue(self.scheduler.register(['zed-updater', '--check'], '03:30'))
+        self.assertEqual(len(self.written), 1)
+
+    def test_read_failure_keeps_crontab(self):
+        """其他错误时不写入 crontab"""
+        with mock.patch('subprocess.run', side_effect=self.fake_run(1, "crontab: permission denied\n")):
+            self.assertFalse(self.scheduler.register(['zed-updater', '--check'], '03:30'))
+            self.assertFalse(self.scheduler.unregister())
+        self.assertEqual(self.written, [])
+
+    def test_percent_escaped(self):
+        """命令中的 % 被转义"""
+        line = self.scheduler.build_cron_line(['zed-updater', '--config', '/home/a/100%/config.json'], '03:30', 24)
+        self.assertIn('100\\%', line)
+        self.assertNotIn('100%', line)
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,85 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+下载速度与剩余时间计算测试
+"""
+
+import sys
+import unittest
+from pathlib import Path
+
+# 添加源码目录到路径
+sys.path.insert(0, str(Path(__file__).parent.parent / 'src'))
+
+from zed_updater.utils.transfer import TransferRateTracker, format_size, format_duration
+
+
+class FakeClock:
+    """可控时钟"""
+
+    def __init__(self):
+        self.now = 0.0
+
+    def __call__(self):
+        return self.now
+
+
+class TestTransferRateTracker(unittest.TestCase):
+    """测试传输速率统计"""
+
+    def setUp(self):
+        self.clock = FakeClock()
+        self.tracker = TransferRateTracker(window_seconds=5.0, clock=self.clock)
+
+    def test_rate_unknown_with_single_sample(self):
+        """单个采样点时速率未知"""
+        self.tracker.update(1000)
+        self.assertEqual(self.tracker.rate, 0.0)
+        self.assertIsNone(self.tracker.eta(10000))
+
+    def test_constant_rate_and_eta(self):
+        """匀速下载时速率和剩余时间正确"""
+        for second in range(4):
+            self.clock.now = float(second)
+            self.tracker.update(second * 1000)
+
+        self.assertAlmostEqual(self.tracker.rate, 1000.0)
+        self.assertAlmostEqual(self.tracker.eta(10000), 7.0)
+
+    def test_window_drops_old_samples(self):
+        """滚动窗口只统计最近的采样"""
+        self.tracker.update(0)
+        self.clock.now = 1.0
+        self.tracker.update(100000)  # 初始突发
+
+        for second in range(2, 20):
+            self.clock.now = float(second)
+            self.tracker.update(100000 + (second - 1) * 1000)
+
+        self.assertAlmostEqual(self.tracker.rate, 1000.0)
+
+    def test_reset(self):
+        """重置后重新开始统计"""
+        self.tracker.update(0)
+        self.clock.now = 1.0
+        self.tracker.update(5000)
+        self.tracker.reset()
+        self.assertEqual(self.tracker.rate, 0.0)
+
+
+class TestFormatting(unittest.TestCase):
+    """测试格式化输出"""
+
+    def test_format_size(self):
+        self.assertEqual(format_size(512), "512 B")
+        self.assertEqual(format_size(1536), "1.5 KB")
+        self.assertEqual(format_size(3 * 1024 * 1024), "3.0 MB")
+
+    def test_format_duration(self):
+        self.assertEqual(format_duration(None), "--:--")
+        self.assertEqual(format_duration(75), "01:15")
+        self.assertEqual(format_duration(3725), "1:02:05")
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,56 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+版本比较测试
+"""
+
+import sys
+import unittest
+from pathlib import Path
+
+# 添加源码目录到路径
+sys.path.insert(0, str(Path(__file__).parent.parent / 'src'))
+
+from zed_updater.utils.version import compare_versions, parse_version
+
+
+class TestCompareVersions(unittest.TestCase):
+    """compare_versions 测试"""
+
+    def test_numeric_components(self):
+        """按数值而不是字符串比较"""
+        self.assertEqual(compare_versions("0.150.10", "0.150.9"), 1)
+        self.assertEqual(compare_versions("v0.149.0", "0.150.0"), -1)
+
+    def test_trailing_zeros(self):
+        """缺少的末尾部分视为 0"""
+        self.assertEqual(compare_versions("0.150.1.0", "v0.150.1"), 0)
+        self.assertEqual(compare_versions("0.150.1.1", "0.150.1"), 1)
+
+    def test_prerelease(self):
+        """预发布版早于对应的正式版"""
+        self.assertEqual(compare_versions("0.151.0-pre", "0.151.0"), -1)
+        self.assertEqual(compare_versions("0.151.0-pre.2", "0.151.0-pre.10"), -1)
+        self.assertEqual(compare_versions("0.151.0-pre.1", "0.151.0-pre"), 1)
+        self.assertEqual(compare_versions("0.151.0-rc.1", "0.151.0-beta"), 1)
+        self.assertEqual(compare_versions("0.151.0-pre", "0.150.2"), 1)
+
+    def test_build_metadata_ignored(self):
+        """+build 元数据不参与比较"""
+        self.assertEqual(compare_versions("1.2.3+abc", "1.2.3"), 0)
+
+    def test_date_versions(self):
+        """日期版本按日期比较"""
+        self.assertEqual(compare_versions("2024-01-15", "2024-02-01"), -1)
+
+    def test_embedded_version(self):
+        """从命令输出中提取版本"""
+        self.assertEqual(parse_version("Zed 0.150.1 abc123"), ((0, 150, 1), ()))
+
+    def test_unparseable(self):
+        """无法解析时返回 None"""
+        self.assertIsNone(compare_versions("latest", "0.150.1"))
+
+
+if __name__ == '__main__':
+    unittest.main()